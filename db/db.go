@@ -14,7 +14,7 @@ import (
 var log *logrus.Logger
 
 func init() {
-	log = logs.InitLogger()
+	log = logs.Default()
 }
 
 type Redis struct {
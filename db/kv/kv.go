@@ -0,0 +1,105 @@
+// Package kv 统一的键值存储接口, 可在 go-redis、redka 和内存实现之间切换
+package kv
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// NoExpiration TTL 返回值, 表示键存在但没有设置过期时间
+const NoExpiration = time.Duration(-1)
+
+var (
+	// ErrNotFound 键或元素不存在
+	ErrNotFound = errors.New("kv: not found")
+	// ErrWrongType 键已存在且类型不匹配
+	ErrWrongType = errors.New("kv: wrong type")
+	// ErrNotInteger 值不是整数
+	ErrNotInteger = errors.New("kv: value is not an integer")
+)
+
+// Z 有序集合成员
+type Z struct {
+	Member string
+	Score  float64
+}
+
+// Cmd 键值命令集合, 语义与 Redis 同名命令一致
+type Cmd interface {
+	// 键
+	Del(ctx context.Context, keys ...string) (int, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Expire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Keys(ctx context.Context, pattern string) ([]string, error)
+
+	// 字符串, ttl 为 0 表示永不过期
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	IncrBy(ctx context.Context, key string, delta int64) (int64, error)
+
+	// 哈希
+	HGet(ctx context.Context, key, field string) (string, error)
+	HSet(ctx context.Context, key string, values map[string]string) (int, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HDel(ctx context.Context, key string, fields ...string) (int, error)
+	HLen(ctx context.Context, key string) (int, error)
+
+	// 列表
+	LPush(ctx context.Context, key string, values ...string) (int, error)
+	RPush(ctx context.Context, key string, values ...string) (int, error)
+	LPop(ctx context.Context, key string) (string, error)
+	RPop(ctx context.Context, key string) (string, error)
+	LRange(ctx context.Context, key string, start, stop int) ([]string, error)
+	LLen(ctx context.Context, key string) (int, error)
+
+	// 集合
+	SAdd(ctx context.Context, key string, members ...string) (int, error)
+	SRem(ctx context.Context, key string, members ...string) (int, error)
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SIsMember(ctx context.Context, key, member string) (bool, error)
+	SCard(ctx context.Context, key string) (int, error)
+
+	// 有序集合
+	ZAdd(ctx context.Context, key string, members ...Z) (int, error)
+	ZRem(ctx context.Context, key string, members ...string) (int, error)
+	ZScore(ctx context.Context, key, member string) (float64, error)
+	ZIncrBy(ctx context.Context, key string, delta float64, member string) (float64, error)
+	ZRange(ctx context.Context, key string, start, stop int) ([]Z, error)
+	ZRangeByScore(ctx context.Context, key string, min, max float64) ([]Z, error)
+	ZCard(ctx context.Context, key string) (int, error)
+}
+
+// KV 键值存储
+type KV interface {
+	Cmd
+
+	// Tx 在事务中执行 fn, fn 返回错误时所有写入都不生效.
+	// Redis 后端基于 MULTI/EXEC: 写命令在提交时才执行, 其返回值在 fn 中为零值,
+	// 读命令直接读取当前数据, 看不到本事务尚未提交的写入.
+	Tx(ctx context.Context, fn func(tx Cmd) error) error
+
+	Close() error
+}
+
+// normalizeRange 将 Redis 风格的下标(支持负数)转换为 [lo, hi] 闭区间
+func normalizeRange(lo, hi, n int) (int, int, bool) {
+	if lo < 0 {
+		lo += n
+	}
+	if hi < 0 {
+		hi += n
+	}
+	if lo < 0 {
+		lo = 0
+	}
+	if hi >= n {
+		hi = n - 1
+	}
+	if lo > hi || n == 0 {
+		return 0, 0, false
+	}
+	return lo, hi, true
+}
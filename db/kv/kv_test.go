@@ -0,0 +1,265 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Fromsko/gouitls/db/small"
+	"github.com/redis/go-redis/v9"
+)
+
+func newRedka(t *testing.T) KV {
+	t.Helper()
+	db, err := small.NewRedDB(small.MemoryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv := NewRedka(db)
+	t.Cleanup(func() { kv.Close() })
+	return kv
+}
+
+// newRedis 使用 small.Server 作为 Redis 服务端
+func newRedis(t *testing.T) KV {
+	t.Helper()
+	db, err := small.NewRedDB(small.MemoryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := small.NewServer(db)
+	go srv.Serve(l)
+
+	kv := NewRedis(redis.NewClient(&redis.Options{Addr: l.Addr().String()}))
+	t.Cleanup(func() {
+		kv.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+		small.Close(db)
+	})
+	return kv
+}
+
+func backends(t *testing.T, test func(t *testing.T, kv KV)) {
+	for name, open := range map[string]func(t *testing.T) KV{
+		"memory": func(*testing.T) KV { return NewMemory() },
+		"redka":  newRedka,
+		"redis":  newRedis,
+	} {
+		t.Run(name, func(t *testing.T) { test(t, open(t)) })
+	}
+}
+
+func TestStrings(t *testing.T) {
+	backends(t, func(t *testing.T, kv KV) {
+		ctx := context.Background()
+
+		if _, err := kv.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		if err := kv.Set(ctx, "name", "alice", 0); err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := kv.Get(ctx, "name"); v != "alice" {
+			t.Errorf("get = %q", v)
+		}
+		if ok, _ := kv.SetNX(ctx, "name", "bob", 0); ok {
+			t.Error("setnx should fail on existing key")
+		}
+		if n, _ := kv.IncrBy(ctx, "counter", 5); n != 5 {
+			t.Errorf("incrby = %d", n)
+		}
+		if _, err := kv.IncrBy(ctx, "name", 1); !errors.Is(err, ErrNotInteger) {
+			t.Errorf("expected ErrNotInteger, got %v", err)
+		}
+		if keys, _ := kv.Keys(ctx, "na*"); !slices.Equal(keys, []string{"name"}) {
+			t.Errorf("keys = %v", keys)
+		}
+		if n, _ := kv.Del(ctx, "name", "missing"); n != 1 {
+			t.Errorf("del = %d", n)
+		}
+	})
+}
+
+func TestTTL(t *testing.T) {
+	backends(t, func(t *testing.T, kv KV) {
+		ctx := context.Background()
+
+		kv.Set(ctx, "a", "1", time.Minute)
+		if ttl, _ := kv.TTL(ctx, "a"); ttl <= 0 || ttl > time.Minute {
+			t.Errorf("ttl = %v", ttl)
+		}
+		kv.Set(ctx, "b", "1", 0)
+		if ttl, _ := kv.TTL(ctx, "b"); ttl != NoExpiration {
+			t.Errorf("expected NoExpiration, got %v", ttl)
+		}
+		if _, err := kv.TTL(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		if ok, _ := kv.Expire(ctx, "missing", time.Minute); ok {
+			t.Error("expire on missing key should return false")
+		}
+
+		kv.Expire(ctx, "b", 10*time.Millisecond)
+		time.Sleep(20 * time.Millisecond)
+		if ok, _ := kv.Exists(ctx, "b"); ok {
+			t.Error("key should have expired")
+		}
+	})
+}
+
+func TestHash(t *testing.T) {
+	backends(t, func(t *testing.T, kv KV) {
+		ctx := context.Background()
+
+		if n, _ := kv.HSet(ctx, "user", map[string]string{"name": "alice", "age": "25"}); n != 2 {
+			t.Errorf("hset = %d", n)
+		}
+		if v, _ := kv.HGet(ctx, "user", "name"); v != "alice" {
+			t.Errorf("hget = %q", v)
+		}
+		if _, err := kv.HGet(ctx, "user", "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		if m, _ := kv.HGetAll(ctx, "user"); len(m) != 2 || m["age"] != "25" {
+			t.Errorf("hgetall = %v", m)
+		}
+		if n, _ := kv.HDel(ctx, "user", "age", "missing"); n != 1 {
+			t.Errorf("hdel = %d", n)
+		}
+		if n, _ := kv.HLen(ctx, "user"); n != 1 {
+			t.Errorf("hlen = %d", n)
+		}
+
+		kv.Set(ctx, "str", "x", 0)
+		if _, err := kv.HSet(ctx, "str", map[string]string{"f": "v"}); !errors.Is(err, ErrWrongType) {
+			t.Errorf("expected ErrWrongType, got %v", err)
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	backends(t, func(t *testing.T, kv KV) {
+		ctx := context.Background()
+
+		kv.RPush(ctx, "list", "b", "c")
+		if n, _ := kv.LPush(ctx, "list", "a"); n != 3 {
+			t.Errorf("lpush = %d", n)
+		}
+		if l, _ := kv.LRange(ctx, "list", 0, -1); !slices.Equal(l, []string{"a", "b", "c"}) {
+			t.Errorf("lrange = %v", l)
+		}
+		if l, _ := kv.LRange(ctx, "list", -2, -1); !slices.Equal(l, []string{"b", "c"}) {
+			t.Errorf("lrange negative = %v", l)
+		}
+		if v, _ := kv.RPop(ctx, "list"); v != "c" {
+			t.Errorf("rpop = %q", v)
+		}
+		if v, _ := kv.LPop(ctx, "list"); v != "a" {
+			t.Errorf("lpop = %q", v)
+		}
+		kv.LPop(ctx, "list")
+		if _, err := kv.LPop(ctx, "list"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		if n, _ := kv.LLen(ctx, "list"); n != 0 {
+			t.Errorf("llen = %d", n)
+		}
+	})
+}
+
+func TestSet(t *testing.T) {
+	backends(t, func(t *testing.T, kv KV) {
+		ctx := context.Background()
+
+		if n, _ := kv.SAdd(ctx, "tags", "go", "redis", "go"); n != 2 {
+			t.Errorf("sadd = %d", n)
+		}
+		if ok, _ := kv.SIsMember(ctx, "tags", "go"); !ok {
+			t.Error("sismember = false")
+		}
+		if n, _ := kv.SRem(ctx, "tags", "redis"); n != 1 {
+			t.Errorf("srem = %d", n)
+		}
+		if m, _ := kv.SMembers(ctx, "tags"); !slices.Equal(m, []string{"go"}) {
+			t.Errorf("smembers = %v", m)
+		}
+		if n, _ := kv.SCard(ctx, "tags"); n != 1 {
+			t.Errorf("scard = %d", n)
+		}
+	})
+}
+
+func TestSortedSet(t *testing.T) {
+	backends(t, func(t *testing.T, kv KV) {
+		ctx := context.Background()
+
+		n, _ := kv.ZAdd(ctx, "rank", Z{"one", 1}, Z{"two", 2}, Z{"three", 3})
+		if n != 3 {
+			t.Errorf("zadd = %d", n)
+		}
+		if s, _ := kv.ZIncrBy(ctx, "rank", 10, "one"); s != 11 {
+			t.Errorf("zincrby = %v", s)
+		}
+		if s, _ := kv.ZScore(ctx, "rank", "two"); s != 2 {
+			t.Errorf("zscore = %v", s)
+		}
+		if _, err := kv.ZScore(ctx, "rank", "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		want := []Z{{"three", 3}, {"one", 11}}
+		if z, _ := kv.ZRange(ctx, "rank", -2, -1); !slices.Equal(z, want) {
+			t.Errorf("zrange = %v", z)
+		}
+		if z, _ := kv.ZRangeByScore(ctx, "rank", 3, math.Inf(1)); !slices.Equal(z, want) {
+			t.Errorf("zrangebyscore = %v", z)
+		}
+		kv.ZRem(ctx, "rank", "one")
+		if n, _ := kv.ZCard(ctx, "rank"); n != 2 {
+			t.Errorf("zcard = %d", n)
+		}
+	})
+}
+
+func TestTx(t *testing.T) {
+	backends(t, func(t *testing.T, kv KV) {
+		ctx := context.Background()
+
+		err := kv.Tx(ctx, func(tx Cmd) error {
+			tx.Set(ctx, "a", "1", 0)
+			tx.HSet(ctx, "h", map[string]string{"f": "v"})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := kv.Get(ctx, "a"); v != "1" {
+			t.Errorf("expected committed value, got %q", v)
+		}
+
+		errAbort := errors.New("abort")
+		err = kv.Tx(ctx, func(tx Cmd) error {
+			tx.Set(ctx, "a", "2", 0)
+			tx.Del(ctx, "h")
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Errorf("expected errAbort, got %v", err)
+		}
+		if v, _ := kv.Get(ctx, "a"); v != "1" {
+			t.Errorf("expected rolled back value, got %q", v)
+		}
+		if ok, _ := kv.Exists(ctx, "h"); !ok {
+			t.Error("expected hash to survive rollback")
+		}
+	})
+}
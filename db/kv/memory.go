@@ -0,0 +1,533 @@
+package kv
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Fromsko/gouitls/db/small"
+)
+
+// entry 内存中的键, value 为 string、map[string]string、*[]string、
+// map[string]struct{} 或 map[string]float64
+type entry struct {
+	value    any
+	expireAt time.Time
+}
+
+func (e *entry) clone() *entry {
+	out := &entry{value: e.value, expireAt: e.expireAt}
+	switch v := e.value.(type) {
+	case map[string]string:
+		out.value = maps.Clone(v)
+	case *[]string:
+		list := slices.Clone(*v)
+		out.value = &list
+	case map[string]struct{}:
+		out.value = maps.Clone(v)
+	case map[string]float64:
+		out.value = maps.Clone(v)
+	}
+	return out
+}
+
+// memCmd 在 data 上执行命令, mu 为空时表示调用方已持有锁(事务中)
+type memCmd struct {
+	mu   *sync.Mutex
+	data map[string]*entry
+}
+
+// memory 内存实现, 适用于单元测试
+type memory struct {
+	memCmd
+	mu sync.Mutex
+}
+
+// NewMemory 创建内存 KV, 事务通过复制数据实现, 仅适用于测试和小数据量场景
+func NewMemory() KV {
+	m := &memory{}
+	m.memCmd = memCmd{mu: &m.mu, data: make(map[string]*entry)}
+	return m
+}
+
+func (m *memory) Tx(ctx context.Context, fn func(tx Cmd) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data := make(map[string]*entry, len(m.data))
+	for key, e := range m.data {
+		data[key] = e.clone()
+	}
+	if err := fn(&memCmd{data: data}); err != nil {
+		return err
+	}
+	m.data = data
+	return nil
+}
+
+func (m *memory) Close() error {
+	return nil
+}
+
+func (c *memCmd) lock() func() {
+	if c.mu == nil {
+		return func() {}
+	}
+	c.mu.Lock()
+	return c.mu.Unlock
+}
+
+// entry 读取未过期的键, 过期的键会被删除
+func (c *memCmd) entry(key string) *entry {
+	e, ok := c.data[key]
+	if !ok {
+		return nil
+	}
+	if !e.expireAt.IsZero() && !time.Now().Before(e.expireAt) {
+		delete(c.data, key)
+		return nil
+	}
+	return e
+}
+
+// dropEmpty 集合类型为空时删除键, 与 Redis 一致
+func (c *memCmd) dropEmpty(key string, n int) {
+	if n == 0 {
+		delete(c.data, key)
+	}
+}
+
+// lookup 读取指定类型的值, 键不存在时 ok 为 false
+func lookup[T any](c *memCmd, key string) (v T, ok bool, err error) {
+	e := c.entry(key)
+	if e == nil {
+		return v, false, nil
+	}
+	if v, ok = e.value.(T); !ok {
+		return v, false, ErrWrongType
+	}
+	return v, true, nil
+}
+
+// create 读取指定类型的值, 键不存在时使用 init 创建
+func create[T any](c *memCmd, key string, init func() T) (T, error) {
+	v, ok, err := lookup[T](c, key)
+	if err != nil || ok {
+		return v, err
+	}
+	v = init()
+	c.data[key] = &entry{value: v}
+	return v, nil
+}
+
+func expireAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+func (c *memCmd) Del(ctx context.Context, keys ...string) (int, error) {
+	defer c.lock()()
+	n := 0
+	for _, key := range keys {
+		if c.entry(key) != nil {
+			delete(c.data, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (c *memCmd) Exists(ctx context.Context, key string) (bool, error) {
+	defer c.lock()()
+	return c.entry(key) != nil, nil
+}
+
+func (c *memCmd) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	defer c.lock()()
+	e := c.entry(key)
+	if e == nil {
+		return false, nil
+	}
+	if ttl <= 0 {
+		delete(c.data, key)
+		return true, nil
+	}
+	e.expireAt = expireAt(ttl)
+	return true, nil
+}
+
+func (c *memCmd) TTL(ctx context.Context, key string) (time.Duration, error) {
+	defer c.lock()()
+	e := c.entry(key)
+	if e == nil {
+		return 0, ErrNotFound
+	}
+	if e.expireAt.IsZero() {
+		return NoExpiration, nil
+	}
+	return time.Until(e.expireAt), nil
+}
+
+func (c *memCmd) Keys(ctx context.Context, pattern string) ([]string, error) {
+	defer c.lock()()
+	var keys []string
+	for key := range c.data {
+		if c.entry(key) != nil && small.MatchGlob(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (c *memCmd) Get(ctx context.Context, key string) (string, error) {
+	defer c.lock()()
+	v, ok, err := lookup[string](c, key)
+	if err == nil && !ok {
+		err = ErrNotFound
+	}
+	return v, err
+}
+
+func (c *memCmd) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	defer c.lock()()
+	c.data[key] = &entry{value: value, expireAt: expireAt(ttl)}
+	return nil
+}
+
+func (c *memCmd) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	defer c.lock()()
+	if c.entry(key) != nil {
+		return false, nil
+	}
+	c.data[key] = &entry{value: value, expireAt: expireAt(ttl)}
+	return true, nil
+}
+
+func (c *memCmd) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	defer c.lock()()
+	v, ok, err := lookup[string](c, key)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	if ok {
+		if n, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, ErrNotInteger
+		}
+	}
+	n += delta
+	if ok {
+		c.data[key].value = strconv.FormatInt(n, 10)
+	} else {
+		c.data[key] = &entry{value: strconv.FormatInt(n, 10)}
+	}
+	return n, nil
+}
+
+func (c *memCmd) HGet(ctx context.Context, key, field string) (string, error) {
+	defer c.lock()()
+	h, _, err := lookup[map[string]string](c, key)
+	if err != nil {
+		return "", err
+	}
+	v, ok := h[field]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+func (c *memCmd) HSet(ctx context.Context, key string, values map[string]string) (int, error) {
+	defer c.lock()()
+	if len(values) == 0 {
+		return 0, nil
+	}
+	h, err := create(c, key, func() map[string]string { return make(map[string]string) })
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for field, value := range values {
+		if _, ok := h[field]; !ok {
+			n++
+		}
+		h[field] = value
+	}
+	return n, nil
+}
+
+func (c *memCmd) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	defer c.lock()()
+	h, _, err := lookup[map[string]string](c, key)
+	if err != nil {
+		return nil, err
+	}
+	return maps.Clone(h), nil
+}
+
+func (c *memCmd) HDel(ctx context.Context, key string, fields ...string) (int, error) {
+	defer c.lock()()
+	h, _, err := lookup[map[string]string](c, key)
+	if err != nil || h == nil {
+		return 0, err
+	}
+	n := 0
+	for _, field := range fields {
+		if _, ok := h[field]; ok {
+			delete(h, field)
+			n++
+		}
+	}
+	c.dropEmpty(key, len(h))
+	return n, nil
+}
+
+func (c *memCmd) HLen(ctx context.Context, key string) (int, error) {
+	defer c.lock()()
+	h, _, err := lookup[map[string]string](c, key)
+	return len(h), err
+}
+
+func (c *memCmd) LPush(ctx context.Context, key string, values ...string) (int, error) {
+	defer c.lock()()
+	l, err := create(c, key, func() *[]string { return new([]string) })
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range values {
+		*l = slices.Insert(*l, 0, v)
+	}
+	return len(*l), nil
+}
+
+func (c *memCmd) RPush(ctx context.Context, key string, values ...string) (int, error) {
+	defer c.lock()()
+	l, err := create(c, key, func() *[]string { return new([]string) })
+	if err != nil {
+		return 0, err
+	}
+	*l = append(*l, values...)
+	return len(*l), nil
+}
+
+func (c *memCmd) pop(key string, front bool) (string, error) {
+	defer c.lock()()
+	l, ok, err := lookup[*[]string](c, key)
+	if err != nil {
+		return "", err
+	}
+	if !ok || len(*l) == 0 {
+		return "", ErrNotFound
+	}
+	var v string
+	if front {
+		v, *l = (*l)[0], (*l)[1:]
+	} else {
+		v, *l = (*l)[len(*l)-1], (*l)[:len(*l)-1]
+	}
+	c.dropEmpty(key, len(*l))
+	return v, nil
+}
+
+func (c *memCmd) LPop(ctx context.Context, key string) (string, error) {
+	return c.pop(key, true)
+}
+
+func (c *memCmd) RPop(ctx context.Context, key string) (string, error) {
+	return c.pop(key, false)
+}
+
+func (c *memCmd) LRange(ctx context.Context, key string, start, stop int) ([]string, error) {
+	defer c.lock()()
+	l, ok, err := lookup[*[]string](c, key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	lo, hi, ok := normalizeRange(start, stop, len(*l))
+	if !ok {
+		return nil, nil
+	}
+	return slices.Clone((*l)[lo : hi+1]), nil
+}
+
+func (c *memCmd) LLen(ctx context.Context, key string) (int, error) {
+	defer c.lock()()
+	l, ok, err := lookup[*[]string](c, key)
+	if err != nil || !ok {
+		return 0, err
+	}
+	return len(*l), nil
+}
+
+func (c *memCmd) SAdd(ctx context.Context, key string, members ...string) (int, error) {
+	defer c.lock()()
+	s, err := create(c, key, func() map[string]struct{} { return make(map[string]struct{}) })
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, m := range members {
+		if _, ok := s[m]; !ok {
+			s[m] = struct{}{}
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (c *memCmd) SRem(ctx context.Context, key string, members ...string) (int, error) {
+	defer c.lock()()
+	s, _, err := lookup[map[string]struct{}](c, key)
+	if err != nil || s == nil {
+		return 0, err
+	}
+	n := 0
+	for _, m := range members {
+		if _, ok := s[m]; ok {
+			delete(s, m)
+			n++
+		}
+	}
+	c.dropEmpty(key, len(s))
+	return n, nil
+}
+
+func (c *memCmd) SMembers(ctx context.Context, key string) ([]string, error) {
+	defer c.lock()()
+	s, _, err := lookup[map[string]struct{}](c, key)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]string, 0, len(s))
+	for m := range s {
+		members = append(members, m)
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+func (c *memCmd) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	defer c.lock()()
+	s, _, err := lookup[map[string]struct{}](c, key)
+	_, ok := s[member]
+	return ok, err
+}
+
+func (c *memCmd) SCard(ctx context.Context, key string) (int, error) {
+	defer c.lock()()
+	s, _, err := lookup[map[string]struct{}](c, key)
+	return len(s), err
+}
+
+func (c *memCmd) ZAdd(ctx context.Context, key string, members ...Z) (int, error) {
+	defer c.lock()()
+	z, err := create(c, key, func() map[string]float64 { return make(map[string]float64) })
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, m := range members {
+		if _, ok := z[m.Member]; !ok {
+			n++
+		}
+		z[m.Member] = m.Score
+	}
+	c.dropEmpty(key, len(z))
+	return n, nil
+}
+
+func (c *memCmd) ZRem(ctx context.Context, key string, members ...string) (int, error) {
+	defer c.lock()()
+	z, _, err := lookup[map[string]float64](c, key)
+	if err != nil || z == nil {
+		return 0, err
+	}
+	n := 0
+	for _, m := range members {
+		if _, ok := z[m]; ok {
+			delete(z, m)
+			n++
+		}
+	}
+	c.dropEmpty(key, len(z))
+	return n, nil
+}
+
+func (c *memCmd) ZScore(ctx context.Context, key, member string) (float64, error) {
+	defer c.lock()()
+	z, _, err := lookup[map[string]float64](c, key)
+	if err != nil {
+		return 0, err
+	}
+	score, ok := z[member]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	return score, nil
+}
+
+func (c *memCmd) ZIncrBy(ctx context.Context, key string, delta float64, member string) (float64, error) {
+	defer c.lock()()
+	z, err := create(c, key, func() map[string]float64 { return make(map[string]float64) })
+	if err != nil {
+		return 0, err
+	}
+	z[member] += delta
+	return z[member], nil
+}
+
+// sorted 按分数和成员排序, 与 Redis 一致
+func sorted(z map[string]float64) []Z {
+	items := make([]Z, 0, len(z))
+	for m, score := range z {
+		items = append(items, Z{Member: m, Score: score})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score < items[j].Score
+		}
+		return items[i].Member < items[j].Member
+	})
+	return items
+}
+
+func (c *memCmd) ZRange(ctx context.Context, key string, start, stop int) ([]Z, error) {
+	defer c.lock()()
+	z, _, err := lookup[map[string]float64](c, key)
+	if err != nil {
+		return nil, err
+	}
+	lo, hi, ok := normalizeRange(start, stop, len(z))
+	if !ok {
+		return nil, nil
+	}
+	return sorted(z)[lo : hi+1], nil
+}
+
+func (c *memCmd) ZRangeByScore(ctx context.Context, key string, min, max float64) ([]Z, error) {
+	defer c.lock()()
+	z, _, err := lookup[map[string]float64](c, key)
+	if err != nil {
+		return nil, err
+	}
+	var items []Z
+	for _, item := range sorted(z) {
+		if item.Score >= min && item.Score <= max {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (c *memCmd) ZCard(ctx context.Context, key string) (int, error) {
+	defer c.lock()()
+	z, _, err := lookup[map[string]float64](c, key)
+	return len(z), err
+}
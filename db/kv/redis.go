@@ -0,0 +1,260 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKV go-redis 适配器, 事务中读写分别走连接和 MULTI 流水线
+type redisKV struct {
+	client redis.UniversalClient
+	r      redis.Cmdable
+	w      redis.Cmdable
+}
+
+// NewRedis 基于 go-redis 客户端创建 KV, 例如 db.NewRedis 的返回值
+func NewRedis(client redis.UniversalClient) KV {
+	return &redisKV{client: client, r: client, w: client}
+}
+
+// redisError 将 go-redis 错误转换为包内错误
+func redisError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, redis.Nil):
+		return ErrNotFound
+	case strings.HasPrefix(err.Error(), "WRONGTYPE"):
+		return ErrWrongType
+	case strings.HasPrefix(err.Error(), "ERR value is not an integer"):
+		return ErrNotInteger
+	}
+	return err
+}
+
+// formatScore 分数转换为 Redis 区间参数
+func formatScore(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func toZ(items []redis.Z) []Z {
+	out := make([]Z, 0, len(items))
+	for _, item := range items {
+		member, _ := item.Member.(string)
+		out = append(out, Z{Member: member, Score: item.Score})
+	}
+	return out
+}
+
+func toAny(values []string) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
+
+func (k *redisKV) Del(ctx context.Context, keys ...string) (int, error) {
+	n, err := k.w.Del(ctx, keys...).Result()
+	return int(n), redisError(err)
+}
+
+func (k *redisKV) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := k.r.Exists(ctx, key).Result()
+	return n > 0, redisError(err)
+}
+
+func (k *redisKV) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := k.w.PExpire(ctx, key, ttl).Result()
+	return ok, redisError(err)
+}
+
+func (k *redisKV) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := k.r.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, redisError(err)
+	}
+	switch ttl {
+	case -2:
+		return 0, ErrNotFound
+	case -1:
+		return NoExpiration, nil
+	}
+	return ttl, nil
+}
+
+func (k *redisKV) Keys(ctx context.Context, pattern string) ([]string, error) {
+	keys, err := k.r.Keys(ctx, pattern).Result()
+	return keys, redisError(err)
+}
+
+func (k *redisKV) Get(ctx context.Context, key string) (string, error) {
+	v, err := k.r.Get(ctx, key).Result()
+	return v, redisError(err)
+}
+
+func (k *redisKV) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return redisError(k.w.Set(ctx, key, value, ttl).Err())
+}
+
+func (k *redisKV) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	ok, err := k.w.SetNX(ctx, key, value, ttl).Result()
+	return ok, redisError(err)
+}
+
+func (k *redisKV) IncrBy(ctx context.Context, key string, delta int64) (int64, error) {
+	n, err := k.w.IncrBy(ctx, key, delta).Result()
+	return n, redisError(err)
+}
+
+func (k *redisKV) HGet(ctx context.Context, key, field string) (string, error) {
+	v, err := k.r.HGet(ctx, key, field).Result()
+	return v, redisError(err)
+}
+
+func (k *redisKV) HSet(ctx context.Context, key string, values map[string]string) (int, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+	args := make([]any, 0, len(values)*2)
+	for field, value := range values {
+		args = append(args, field, value)
+	}
+	n, err := k.w.HSet(ctx, key, args...).Result()
+	return int(n), redisError(err)
+}
+
+func (k *redisKV) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	m, err := k.r.HGetAll(ctx, key).Result()
+	return m, redisError(err)
+}
+
+func (k *redisKV) HDel(ctx context.Context, key string, fields ...string) (int, error) {
+	n, err := k.w.HDel(ctx, key, fields...).Result()
+	return int(n), redisError(err)
+}
+
+func (k *redisKV) HLen(ctx context.Context, key string) (int, error) {
+	n, err := k.r.HLen(ctx, key).Result()
+	return int(n), redisError(err)
+}
+
+func (k *redisKV) LPush(ctx context.Context, key string, values ...string) (int, error) {
+	n, err := k.w.LPush(ctx, key, toAny(values)...).Result()
+	return int(n), redisError(err)
+}
+
+func (k *redisKV) RPush(ctx context.Context, key string, values ...string) (int, error) {
+	n, err := k.w.RPush(ctx, key, toAny(values)...).Result()
+	return int(n), redisError(err)
+}
+
+func (k *redisKV) LPop(ctx context.Context, key string) (string, error) {
+	v, err := k.w.LPop(ctx, key).Result()
+	return v, redisError(err)
+}
+
+func (k *redisKV) RPop(ctx context.Context, key string) (string, error) {
+	v, err := k.w.RPop(ctx, key).Result()
+	return v, redisError(err)
+}
+
+func (k *redisKV) LRange(ctx context.Context, key string, start, stop int) ([]string, error) {
+	values, err := k.r.LRange(ctx, key, int64(start), int64(stop)).Result()
+	return values, redisError(err)
+}
+
+func (k *redisKV) LLen(ctx context.Context, key string) (int, error) {
+	n, err := k.r.LLen(ctx, key).Result()
+	return int(n), redisError(err)
+}
+
+func (k *redisKV) SAdd(ctx context.Context, key string, members ...string) (int, error) {
+	n, err := k.w.SAdd(ctx, key, toAny(members)...).Result()
+	return int(n), redisError(err)
+}
+
+func (k *redisKV) SRem(ctx context.Context, key string, members ...string) (int, error) {
+	n, err := k.w.SRem(ctx, key, toAny(members)...).Result()
+	return int(n), redisError(err)
+}
+
+func (k *redisKV) SMembers(ctx context.Context, key string) ([]string, error) {
+	members, err := k.r.SMembers(ctx, key).Result()
+	return members, redisError(err)
+}
+
+func (k *redisKV) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	ok, err := k.r.SIsMember(ctx, key, member).Result()
+	return ok, redisError(err)
+}
+
+func (k *redisKV) SCard(ctx context.Context, key string) (int, error) {
+	n, err := k.r.SCard(ctx, key).Result()
+	return int(n), redisError(err)
+}
+
+func (k *redisKV) ZAdd(ctx context.Context, key string, members ...Z) (int, error) {
+	items := make([]redis.Z, len(members))
+	for i, m := range members {
+		items[i] = redis.Z{Score: m.Score, Member: m.Member}
+	}
+	n, err := k.w.ZAdd(ctx, key, items...).Result()
+	return int(n), redisError(err)
+}
+
+func (k *redisKV) ZRem(ctx context.Context, key string, members ...string) (int, error) {
+	n, err := k.w.ZRem(ctx, key, toAny(members)...).Result()
+	return int(n), redisError(err)
+}
+
+func (k *redisKV) ZScore(ctx context.Context, key, member string) (float64, error) {
+	score, err := k.r.ZScore(ctx, key, member).Result()
+	return score, redisError(err)
+}
+
+func (k *redisKV) ZIncrBy(ctx context.Context, key string, delta float64, member string) (float64, error) {
+	score, err := k.w.ZIncrBy(ctx, key, delta, member).Result()
+	return score, redisError(err)
+}
+
+func (k *redisKV) ZRange(ctx context.Context, key string, start, stop int) ([]Z, error) {
+	items, err := k.r.ZRangeWithScores(ctx, key, int64(start), int64(stop)).Result()
+	return toZ(items), redisError(err)
+}
+
+func (k *redisKV) ZRangeByScore(ctx context.Context, key string, min, max float64) ([]Z, error) {
+	items, err := k.r.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min: formatScore(min),
+		Max: formatScore(max),
+	}).Result()
+	return toZ(items), redisError(err)
+}
+
+func (k *redisKV) ZCard(ctx context.Context, key string) (int, error) {
+	n, err := k.r.ZCard(ctx, key).Result()
+	return int(n), redisError(err)
+}
+
+func (k *redisKV) Tx(ctx context.Context, fn func(tx Cmd) error) error {
+	_, err := k.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		return fn(&redisKV{client: k.client, r: k.client, w: pipe})
+	})
+	return redisError(err)
+}
+
+func (k *redisKV) Close() error {
+	return k.client.Close()
+}
@@ -0,0 +1,469 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nalgeon/redka"
+)
+
+// redkaKV redka 适配器, tx 不为空时所有命令在该事务中执行
+type redkaKV struct {
+	db *redka.DB
+	tx *redka.Tx
+}
+
+// NewRedka 基于 redka 创建 KV, 例如 small.NewRedDB 的返回值
+func NewRedka(db *redka.DB) KV {
+	return &redkaKV{db: db}
+}
+
+// redkaError 将 redka 错误转换为包内错误
+func redkaError(err error) error {
+	switch {
+	case errors.Is(err, redka.ErrNotFound):
+		return ErrNotFound
+	case errors.Is(err, redka.ErrKeyType):
+		return ErrWrongType
+	case errors.Is(err, redka.ErrValueType):
+		return ErrNotInteger
+	}
+	return err
+}
+
+func (k *redkaKV) update(ctx context.Context, f func(tx *redka.Tx) error) error {
+	if k.tx != nil {
+		return redkaError(f(k.tx))
+	}
+	return redkaError(k.db.UpdateContext(ctx, f))
+}
+
+func (k *redkaKV) view(ctx context.Context, f func(tx *redka.Tx) error) error {
+	if k.tx != nil {
+		return redkaError(f(k.tx))
+	}
+	return redkaError(k.db.ViewContext(ctx, f))
+}
+
+// checkType redka 读取其他类型的键时返回空值, 这里补充 Redis 的 WRONGTYPE 语义
+func checkType(tx *redka.Tx, key string, typ redka.TypeID) error {
+	k, err := tx.Key().Get(key)
+	if err == nil && k.Type != typ {
+		return redka.ErrKeyType
+	}
+	return nil
+}
+
+func (k *redkaKV) Del(ctx context.Context, keys ...string) (n int, err error) {
+	err = k.update(ctx, func(tx *redka.Tx) error {
+		n, err = tx.Key().Delete(keys...)
+		return err
+	})
+	return n, err
+}
+
+func (k *redkaKV) Exists(ctx context.Context, key string) (ok bool, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		ok, err = tx.Key().Exists(key)
+		return err
+	})
+	return ok, err
+}
+
+func (k *redkaKV) Expire(ctx context.Context, key string, ttl time.Duration) (ok bool, err error) {
+	err = k.update(ctx, func(tx *redka.Tx) error {
+		if ttl <= 0 {
+			n, err := tx.Key().Delete(key)
+			ok = n > 0
+			return err
+		}
+		err := tx.Key().Expire(key, ttl)
+		if errors.Is(err, redka.ErrNotFound) {
+			return nil
+		}
+		ok = err == nil
+		return err
+	})
+	return ok, err
+}
+
+func (k *redkaKV) TTL(ctx context.Context, key string) (ttl time.Duration, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		rk, err := tx.Key().Get(key)
+		if err != nil {
+			return err
+		}
+		ttl = NoExpiration
+		if rk.ETime != nil {
+			ttl = time.Until(time.UnixMilli(*rk.ETime))
+		}
+		return nil
+	})
+	return ttl, err
+}
+
+func (k *redkaKV) Keys(ctx context.Context, pattern string) (keys []string, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		items, err := tx.Key().Keys(pattern)
+		for _, item := range items {
+			keys = append(keys, item.Key)
+		}
+		return err
+	})
+	return keys, err
+}
+
+func (k *redkaKV) Get(ctx context.Context, key string) (v string, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		val, err := tx.Str().Get(key)
+		if errors.Is(err, redka.ErrNotFound) {
+			if terr := checkType(tx, key, redka.TypeString); terr != nil {
+				return terr
+			}
+		}
+		v = val.String()
+		return err
+	})
+	return v, err
+}
+
+func (k *redkaKV) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return k.update(ctx, func(tx *redka.Tx) error {
+		// 与 Redis 一致, SET 覆盖任意类型的键
+		if err := checkType(tx, key, redka.TypeString); err != nil {
+			if _, err := tx.Key().Delete(key); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Str().SetWith(key, value).TTL(ttl).Run()
+		return err
+	})
+}
+
+func (k *redkaKV) SetNX(ctx context.Context, key, value string, ttl time.Duration) (ok bool, err error) {
+	err = k.update(ctx, func(tx *redka.Tx) error {
+		if exists, err := tx.Key().Exists(key); err != nil || exists {
+			return err
+		}
+		out, err := tx.Str().SetWith(key, value).TTL(ttl).Run()
+		ok = out.Created
+		return err
+	})
+	return ok, err
+}
+
+func (k *redkaKV) IncrBy(ctx context.Context, key string, delta int64) (n int64, err error) {
+	err = k.update(ctx, func(tx *redka.Tx) error {
+		v, err := tx.Str().Incr(key, int(delta))
+		n = int64(v)
+		return err
+	})
+	return n, err
+}
+
+func (k *redkaKV) HGet(ctx context.Context, key, field string) (v string, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		val, err := tx.Hash().Get(key, field)
+		if errors.Is(err, redka.ErrNotFound) {
+			if terr := checkType(tx, key, redka.TypeHash); terr != nil {
+				return terr
+			}
+		}
+		v = val.String()
+		return err
+	})
+	return v, err
+}
+
+func (k *redkaKV) HSet(ctx context.Context, key string, values map[string]string) (n int, err error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+	items := make(map[string]any, len(values))
+	for field, value := range values {
+		items[field] = value
+	}
+	err = k.update(ctx, func(tx *redka.Tx) error {
+		n, err = tx.Hash().SetMany(key, items)
+		return err
+	})
+	return n, err
+}
+
+func (k *redkaKV) HGetAll(ctx context.Context, key string) (m map[string]string, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		items, err := tx.Hash().Items(key)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			if err := checkType(tx, key, redka.TypeHash); err != nil {
+				return err
+			}
+		}
+		m = make(map[string]string, len(items))
+		for field, value := range items {
+			m[field] = value.String()
+		}
+		return nil
+	})
+	return m, err
+}
+
+func (k *redkaKV) HDel(ctx context.Context, key string, fields ...string) (n int, err error) {
+	err = k.update(ctx, func(tx *redka.Tx) error {
+		n, err = tx.Hash().Delete(key, fields...)
+		return err
+	})
+	return n, err
+}
+
+func (k *redkaKV) HLen(ctx context.Context, key string) (n int, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		if n, err = tx.Hash().Len(key); err == nil && n == 0 {
+			return checkType(tx, key, redka.TypeHash)
+		}
+		return err
+	})
+	return n, err
+}
+
+func (k *redkaKV) push(ctx context.Context, key string, values []string, front bool) (n int, err error) {
+	err = k.update(ctx, func(tx *redka.Tx) error {
+		for _, v := range values {
+			if front {
+				n, err = tx.List().PushFront(key, v)
+			} else {
+				n, err = tx.List().PushBack(key, v)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+func (k *redkaKV) LPush(ctx context.Context, key string, values ...string) (int, error) {
+	return k.push(ctx, key, values, true)
+}
+
+func (k *redkaKV) RPush(ctx context.Context, key string, values ...string) (int, error) {
+	return k.push(ctx, key, values, false)
+}
+
+func (k *redkaKV) pop(ctx context.Context, key string, front bool) (v string, err error) {
+	err = k.update(ctx, func(tx *redka.Tx) error {
+		var val redka.Value
+		if front {
+			val, err = tx.List().PopFront(key)
+		} else {
+			val, err = tx.List().PopBack(key)
+		}
+		if errors.Is(err, redka.ErrNotFound) {
+			if terr := checkType(tx, key, redka.TypeList); terr != nil {
+				return terr
+			}
+		}
+		v = val.String()
+		return err
+	})
+	return v, err
+}
+
+func (k *redkaKV) LPop(ctx context.Context, key string) (string, error) {
+	return k.pop(ctx, key, true)
+}
+
+func (k *redkaKV) RPop(ctx context.Context, key string) (string, error) {
+	return k.pop(ctx, key, false)
+}
+
+func (k *redkaKV) LRange(ctx context.Context, key string, start, stop int) (values []string, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		items, err := tx.List().Range(key, start, stop)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			if err := checkType(tx, key, redka.TypeList); err != nil {
+				return err
+			}
+		}
+		values = make([]string, 0, len(items))
+		for _, item := range items {
+			values = append(values, item.String())
+		}
+		return nil
+	})
+	return values, err
+}
+
+func (k *redkaKV) LLen(ctx context.Context, key string) (n int, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		if n, err = tx.List().Len(key); err == nil && n == 0 {
+			return checkType(tx, key, redka.TypeList)
+		}
+		return err
+	})
+	return n, err
+}
+
+func (k *redkaKV) SAdd(ctx context.Context, key string, members ...string) (n int, err error) {
+	err = k.update(ctx, func(tx *redka.Tx) error {
+		n, err = tx.Set().Add(key, toAny(members)...)
+		return err
+	})
+	return n, err
+}
+
+func (k *redkaKV) SRem(ctx context.Context, key string, members ...string) (n int, err error) {
+	err = k.update(ctx, func(tx *redka.Tx) error {
+		n, err = tx.Set().Delete(key, toAny(members)...)
+		return err
+	})
+	return n, err
+}
+
+func (k *redkaKV) SMembers(ctx context.Context, key string) (members []string, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		items, err := tx.Set().Items(key)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			if err := checkType(tx, key, redka.TypeSet); err != nil {
+				return err
+			}
+		}
+		members = make([]string, 0, len(items))
+		for _, item := range items {
+			members = append(members, item.String())
+		}
+		return nil
+	})
+	return members, err
+}
+
+func (k *redkaKV) SIsMember(ctx context.Context, key, member string) (ok bool, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		if ok, err = tx.Set().Exists(key, member); err == nil && !ok {
+			return checkType(tx, key, redka.TypeSet)
+		}
+		return err
+	})
+	return ok, err
+}
+
+func (k *redkaKV) SCard(ctx context.Context, key string) (n int, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		if n, err = tx.Set().Len(key); err == nil && n == 0 {
+			return checkType(tx, key, redka.TypeSet)
+		}
+		return err
+	})
+	return n, err
+}
+
+func (k *redkaKV) ZAdd(ctx context.Context, key string, members ...Z) (n int, err error) {
+	items := make(map[any]float64, len(members))
+	for _, m := range members {
+		items[m.Member] = m.Score
+	}
+	err = k.update(ctx, func(tx *redka.Tx) error {
+		n, err = tx.ZSet().AddMany(key, items)
+		return err
+	})
+	return n, err
+}
+
+func (k *redkaKV) ZRem(ctx context.Context, key string, members ...string) (n int, err error) {
+	err = k.update(ctx, func(tx *redka.Tx) error {
+		n, err = tx.ZSet().Delete(key, toAny(members)...)
+		return err
+	})
+	return n, err
+}
+
+func (k *redkaKV) ZScore(ctx context.Context, key, member string) (score float64, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		score, err = tx.ZSet().GetScore(key, member)
+		if errors.Is(err, redka.ErrNotFound) {
+			if terr := checkType(tx, key, redka.TypeZSet); terr != nil {
+				return terr
+			}
+		}
+		return err
+	})
+	return score, err
+}
+
+func (k *redkaKV) ZIncrBy(ctx context.Context, key string, delta float64, member string) (score float64, err error) {
+	err = k.update(ctx, func(tx *redka.Tx) error {
+		score, err = tx.ZSet().Incr(key, member, delta)
+		return err
+	})
+	return score, err
+}
+
+func (k *redkaKV) ZRange(ctx context.Context, key string, start, stop int) (items []Z, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		n, err := tx.ZSet().Len(key)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return checkType(tx, key, redka.TypeZSet)
+		}
+		// redka 的 ByRank 不支持负数下标
+		lo, hi, ok := normalizeRange(start, stop, n)
+		if !ok {
+			return nil
+		}
+		out, err := tx.ZSet().RangeWith(key).ByRank(lo, hi).Run()
+		for _, item := range out {
+			items = append(items, Z{Member: item.Elem.String(), Score: item.Score})
+		}
+		return err
+	})
+	return items, err
+}
+
+func (k *redkaKV) ZRangeByScore(ctx context.Context, key string, min, max float64) (items []Z, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		out, err := tx.ZSet().RangeWith(key).ByScore(min, max).Run()
+		if err != nil {
+			return err
+		}
+		if len(out) == 0 {
+			return checkType(tx, key, redka.TypeZSet)
+		}
+		for _, item := range out {
+			items = append(items, Z{Member: item.Elem.String(), Score: item.Score})
+		}
+		return nil
+	})
+	return items, err
+}
+
+func (k *redkaKV) ZCard(ctx context.Context, key string) (n int, err error) {
+	err = k.view(ctx, func(tx *redka.Tx) error {
+		if n, err = tx.ZSet().Len(key); err == nil && n == 0 {
+			return checkType(tx, key, redka.TypeZSet)
+		}
+		return err
+	})
+	return n, err
+}
+
+func (k *redkaKV) Tx(ctx context.Context, fn func(tx Cmd) error) error {
+	return redkaError(k.db.UpdateContext(ctx, func(tx *redka.Tx) error {
+		return fn(&redkaKV{db: k.db, tx: tx})
+	}))
+}
+
+func (k *redkaKV) Close() error {
+	return k.db.Close()
+}
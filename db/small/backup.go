@@ -0,0 +1,446 @@
+package small
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nalgeon/redka"
+)
+
+// redka 依赖的数据表, 用于校验快照
+var redkaTables = []string{"rkey", "rstring", "rhash", "rlist", "rset", "rzset"}
+
+const (
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".db"
+	snapshotLayout = "20060102T150405.000"
+)
+
+// Backup 在线备份到 dest, 备份期间不阻塞写入
+func Backup(ctx context.Context, db *redka.DB, dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("backup destination %q already exists", dest)
+	}
+	// VACUUM INTO 生成一致且压缩过的数据库副本
+	_, err := db.RO.ExecContext(ctx, "vacuum into ?", dest)
+	return err
+}
+
+// Validate 校验快照文件的完整性和结构
+func Validate(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	conn, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var result string
+	if err := conn.QueryRow("pragma integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("validate %q: %w", path, err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("validate %q: integrity check failed: %s", path, result)
+	}
+
+	for _, table := range redkaTables {
+		var name string
+		err := conn.QueryRow(
+			"select name from sqlite_master where type = 'table' and name = ?", table,
+		).Scan(&name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("validate %q: missing table %s", path, table)
+		}
+		if err != nil {
+			return fmt.Errorf("validate %q: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// rename 替换数据库文件, 测试中用于模拟失败
+var rename = os.Rename
+
+// Restore 校验快照后关闭 db, 用快照替换 path 处的数据库文件并重新打开.
+// 关闭 db 后替换失败时重新打开原来的数据库, 与错误一起返回
+func Restore(db *redka.DB, path, snapshot string, opts ...Option) (*redka.DB, error) {
+	if path == "" || path == MemoryPath {
+		return nil, fmt.Errorf("restore: unsupported path %q", path)
+	}
+	if err := Validate(snapshot); err != nil {
+		return nil, err
+	}
+
+	// 先复制到临时文件, 保证替换是原子的
+	tmp := path + ".restore"
+	if err := copyFile(snapshot, tmp); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	// 数据写回主文件, 替换失败时重新打开的数据库不会因删除 WAL 丢失数据
+	if _, err := db.RW.Exec("pragma wal_checkpoint(truncate)"); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	if err := Close(db); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	if err := replaceFile(tmp, path); err != nil {
+		os.Remove(tmp)
+		reopened, openErr := NewRedDB(path, opts...)
+		if openErr != nil {
+			return nil, errors.Join(err, openErr)
+		}
+		return reopened, err
+	}
+	return NewRedDB(path, opts...)
+}
+
+// replaceFile 用 tmp 替换 path 处已关闭的数据库文件
+func replaceFile(tmp, path string) error {
+	// 旧的 WAL 文件会覆盖新数据, 必须在替换前删除
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return rename(tmp, path)
+}
+
+// copyFile 复制文件并落盘
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// SnapshotOption 快照选项
+type SnapshotOption func(*Snapshotter)
+
+// WithInterval 设置快照间隔
+func WithInterval(interval time.Duration) SnapshotOption {
+	return func(s *Snapshotter) {
+		s.interval = interval
+	}
+}
+
+// WithRetention 设置保留的快照数量, 0 表示全部保留
+func WithRetention(keep int) SnapshotOption {
+	return func(s *Snapshotter) {
+		s.keep = keep
+	}
+}
+
+// WithSnapshotLogger 设置快照日志
+func WithSnapshotLogger(logger *slog.Logger) SnapshotOption {
+	return func(s *Snapshotter) {
+		s.logger = logger
+	}
+}
+
+// Snapshotter 定期快照
+type Snapshotter struct {
+	db       *redka.DB
+	dir      string
+	interval time.Duration
+	keep     int
+	logger   *slog.Logger
+}
+
+// NewSnapshotter 创建定期快照, 快照保存在 dir 目录下
+func NewSnapshotter(db *redka.DB, dir string, opts ...SnapshotOption) *Snapshotter {
+	s := &Snapshotter{
+		db:       db,
+		dir:      dir,
+		interval: time.Hour,
+		keep:     24,
+		logger:   slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Snapshot 立即生成一个快照并清理过期快照, 返回快照路径
+func (s *Snapshotter) Snapshot(ctx context.Context) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", err
+	}
+
+	name := snapshotPrefix + time.Now().UTC().Format(snapshotLayout) + snapshotSuffix
+	path := filepath.Join(s.dir, name)
+	if err := Backup(ctx, s.db, path); err != nil {
+		return "", err
+	}
+	return path, s.prune()
+}
+
+// List 列出所有快照, 按时间从旧到新排序
+func (s *Snapshotter) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotSuffix) {
+			continue
+		}
+		paths = append(paths, filepath.Join(s.dir, name))
+	}
+	// 文件名中的时间可以按字典序排序
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Latest 返回最新的快照, 没有快照时返回空字符串
+func (s *Snapshotter) Latest() (string, error) {
+	paths, err := s.List()
+	if err != nil || len(paths) == 0 {
+		return "", err
+	}
+	return paths[len(paths)-1], nil
+}
+
+// Run 按间隔生成快照, 阻塞直到 ctx 取消
+func (s *Snapshotter) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			path, err := s.Snapshot(ctx)
+			if err != nil {
+				s.logger.Error("snapshot: create", "dir", s.dir, "err", err)
+				continue
+			}
+			s.logger.Info("snapshot: create", "path", path)
+		}
+	}
+}
+
+// prune 删除超出保留数量的旧快照
+func (s *Snapshotter) prune() error {
+	if s.keep <= 0 {
+		return nil
+	}
+	paths, err := s.List()
+	if err != nil {
+		return err
+	}
+	for len(paths) > s.keep {
+		if err := os.Remove(paths[0]); err != nil {
+			return err
+		}
+		paths = paths[1:]
+	}
+	return nil
+}
+
+// ZItem 有序集合元素
+type ZItem struct {
+	Elem  []byte  `json:"elem"`
+	Score float64 `json:"score"`
+}
+
+// DumpRecord 导出文件中的一行, 对应一个键
+type DumpRecord struct {
+	Key      string            `json:"key"`
+	Type     string            `json:"type"`
+	ExpireAt *int64            `json:"expire_at,omitempty"` // 过期时间, unix 毫秒
+	Value    []byte            `json:"value,omitempty"`
+	Hash     map[string][]byte `json:"hash,omitempty"`
+	List     [][]byte          `json:"list,omitempty"`
+	Set      [][]byte          `json:"set,omitempty"`
+	ZSet     []ZItem           `json:"zset,omitempty"`
+}
+
+// Export 将所有键导出为 JSON Lines, 返回导出的键数量
+func Export(ctx context.Context, db *redka.DB, w io.Writer) (int, error) {
+	count := 0
+	enc := json.NewEncoder(w)
+	err := db.ViewContext(ctx, func(tx *redka.Tx) error {
+		scanner := tx.Key().Scanner("*", redka.TypeAny, scanBatch)
+		for scanner.Scan() {
+			rec, err := dumpKey(tx, scanner.Key())
+			if err != nil {
+				return err
+			}
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+			count++
+		}
+		return scanner.Err()
+	})
+	return count, err
+}
+
+// dumpKey 读取单个键的全部数据
+func dumpKey(tx *redka.Tx, key redka.Key) (*DumpRecord, error) {
+	rec := &DumpRecord{Key: key.Key, Type: key.TypeName(), ExpireAt: key.ETime}
+
+	switch key.Type {
+	case redka.TypeString:
+		value, err := tx.Str().Get(key.Key)
+		if err != nil {
+			return nil, err
+		}
+		rec.Value = value.Bytes()
+	case redka.TypeHash:
+		items, err := tx.Hash().Items(key.Key)
+		if err != nil {
+			return nil, err
+		}
+		rec.Hash = make(map[string][]byte, len(items))
+		for field, value := range items {
+			rec.Hash[field] = value.Bytes()
+		}
+	case redka.TypeList:
+		values, err := tx.List().Range(key.Key, 0, -1)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			rec.List = append(rec.List, value.Bytes())
+		}
+	case redka.TypeSet:
+		values, err := tx.Set().Items(key.Key)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			rec.Set = append(rec.Set, value.Bytes())
+		}
+	case redka.TypeZSet:
+		items, err := tx.ZSet().RangeWith(key.Key).ByRank(0, math.MaxInt32).Run()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			rec.ZSet = append(rec.ZSet, ZItem{Elem: item.Elem.Bytes(), Score: item.Score})
+		}
+	default:
+		return nil, fmt.Errorf("export %q: unknown key type %d", key.Key, key.Type)
+	}
+	return rec, nil
+}
+
+// Import 在一个事务中导入 Export 生成的数据, 覆盖同名键, 跳过已过期的键
+func Import(ctx context.Context, db *redka.DB, r io.Reader) (int, error) {
+	count := 0
+	err := db.UpdateContext(ctx, func(tx *redka.Tx) error {
+		count = 0
+		now := time.Now().UnixMilli()
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+		for line := 1; scanner.Scan(); line++ {
+			if len(strings.TrimSpace(scanner.Text())) == 0 {
+				continue
+			}
+			rec := &DumpRecord{}
+			if err := json.Unmarshal(scanner.Bytes(), rec); err != nil {
+				return fmt.Errorf("import line %d: %w", line, err)
+			}
+			if rec.ExpireAt != nil && *rec.ExpireAt <= now {
+				continue
+			}
+			if err := loadKey(tx, rec); err != nil {
+				return fmt.Errorf("import line %d: %w", line, err)
+			}
+			count++
+		}
+		return scanner.Err()
+	})
+	return count, err
+}
+
+// loadKey 写入单个键的全部数据
+func loadKey(tx *redka.Tx, rec *DumpRecord) error {
+	if _, err := tx.Key().Delete(rec.Key); err != nil {
+		return err
+	}
+
+	var err error
+	switch rec.Type {
+	case "string":
+		err = tx.Str().Set(rec.Key, rec.Value)
+	case "hash":
+		items := make(map[string]any, len(rec.Hash))
+		for field, value := range rec.Hash {
+			items[field] = value
+		}
+		_, err = tx.Hash().SetMany(rec.Key, items)
+	case "list":
+		for _, value := range rec.List {
+			if _, err = tx.List().PushBack(rec.Key, value); err != nil {
+				break
+			}
+		}
+	case "set":
+		elems := make([]any, len(rec.Set))
+		for i, value := range rec.Set {
+			elems[i] = value
+		}
+		_, err = tx.Set().Add(rec.Key, elems...)
+	case "zset":
+		items := make(map[any]float64, len(rec.ZSet))
+		for _, item := range rec.ZSet {
+			items[string(item.Elem)] = item.Score
+		}
+		_, err = tx.ZSet().AddMany(rec.Key, items)
+	default:
+		return fmt.Errorf("key %q: unknown type %q", rec.Key, rec.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	if rec.ExpireAt != nil {
+		return tx.Key().ExpireAt(rec.Key, time.UnixMilli(*rec.ExpireAt))
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package small
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nalgeon/redka"
+)
+
+func seed(t *testing.T, db *redka.DB) {
+	t.Helper()
+	err := db.Update(func(tx *redka.Tx) error {
+		tx.Str().Set("str", "value")
+		tx.Str().SetExpires("tmp", "soon", time.Hour)
+		tx.Hash().Set("hash", "field", "value")
+		tx.List().PushBack("list", "a")
+		tx.List().PushBack("list", "b")
+		tx.Set().Add("set", "x", "y")
+		_, err := tx.ZSet().AddMany("zset", map[any]float64{"one": 1, "two": 2})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	src := newTestDB(t)
+	seed(t, src)
+
+	var dump bytes.Buffer
+	count, err := Export(ctx, src, &dump)
+	if err != nil || count != 6 {
+		t.Fatalf("export: count=%d err=%v", count, err)
+	}
+
+	dest := newTestDB(t)
+	dest.Str().Set("str", "stale")
+	count, err = Import(ctx, dest, &dump)
+	if err != nil || count != 6 {
+		t.Fatalf("import: count=%d err=%v", count, err)
+	}
+
+	if v, _ := dest.Str().Get("str"); v.String() != "value" {
+		t.Errorf("unexpected str: %s", v)
+	}
+	if v, _ := dest.Hash().Get("hash", "field"); v.String() != "value" {
+		t.Errorf("unexpected hash field: %s", v)
+	}
+	if v, _ := dest.List().Range("list", 0, -1); len(v) != 2 || v[1].String() != "b" {
+		t.Errorf("unexpected list: %v", v)
+	}
+	if n, _ := dest.Set().Len("set"); n != 2 {
+		t.Errorf("unexpected set len: %d", n)
+	}
+	if s, _ := dest.ZSet().GetScore("zset", "two"); s != 2 {
+		t.Errorf("unexpected zset score: %v", s)
+	}
+	if k, _ := dest.Key().Get("tmp"); k.ETime == nil {
+		t.Errorf("expected ttl to be imported")
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	db := newTestDB(t)
+	_, err := Import(context.Background(), db, strings.NewReader("{\"key\":\"a\",\"type\":\"string\",\"value\":\"YQ==\"}\nnot json\n"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := db.Str().Get("a"); err != redka.ErrNotFound {
+		t.Errorf("expected rollback, got %v", err)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.db")
+
+	db, err := NewRedDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed(t, db)
+
+	snap := NewSnapshotter(db, filepath.Join(dir, "snapshots"), WithRetention(2))
+	for i := 0; i < 3; i++ {
+		if _, err := snap.Snapshot(ctx); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	paths, err := snap.List()
+	if err != nil || len(paths) != 2 {
+		t.Fatalf("expected 2 snapshots, got %v (%v)", paths, err)
+	}
+
+	latest, _ := snap.Latest()
+	db.Str().Set("str", "changed")
+
+	db, err = Restore(db, path, latest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(db)
+	if v, _ := db.Str().Get("str"); v.String() != "value" {
+		t.Errorf("expected restored value, got %s", v)
+	}
+}
+
+func TestRestoreInvalidSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.db")
+	db, err := NewRedDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(db)
+	db.Str().Set("str", "value")
+
+	bad := filepath.Join(dir, "bad.db")
+	os.WriteFile(bad, []byte("not a database"), 0o644)
+	if _, err := Restore(db, path, bad); err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	// 校验失败时原数据库保持可用
+	if v, err := db.Str().Get("str"); err != nil || v.String() != "value" {
+		t.Errorf("expected db to stay open, got %s (%v)", v, err)
+	}
+}
+
+func TestRestoreRenameFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.db")
+	db, err := NewRedDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Str().Set("str", "value")
+
+	snapshot := filepath.Join(dir, "snap.db")
+	if err := Backup(context.Background(), db, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	db.Str().Set("str", "changed")
+
+	rename = func(string, string) error { return errors.New("rename failed") }
+	t.Cleanup(func() { rename = os.Rename })
+	db, err = Restore(db, path, snapshot)
+	if err == nil || db == nil {
+		t.Fatalf("expected error with reopened db, got %v %v", db, err)
+	}
+	defer Close(db)
+
+	// 重新打开的是原来的数据库, 临时文件已删除
+	if v, err := db.Str().Get("str"); err != nil || v.String() != "changed" {
+		t.Errorf("expected original db, got %s (%v)", v, err)
+	}
+	if _, err := os.Stat(path + ".restore"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+}
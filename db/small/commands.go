@@ -0,0 +1,1491 @@
+package small
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nalgeon/redka"
+)
+
+var (
+	errSyntax     = errors.New("ERR syntax error")
+	errNotInt     = errors.New("ERR value is not an integer or out of range")
+	errNotFloat   = errors.New("ERR value is not a valid float")
+	errNoSuchKey  = errors.New("ERR no such key")
+	errIndexRange = errors.New("ERR index out of range")
+	errWrongType  = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+)
+
+// replyError 将 redka 错误转换为 Redis 风格的错误信息
+func replyError(err error) string {
+	switch {
+	case errors.Is(err, redka.ErrKeyType):
+		return errWrongType.Error()
+	case errors.Is(err, redka.ErrValueType):
+		return errNotInt.Error()
+	}
+	return err.Error()
+}
+
+// command 命令定义, arity 与 Redis 一致: 正数为精确参数个数, 负数为最少参数个数(均包含命令名)
+type command struct {
+	arity    int
+	readonly bool
+	run      func(c *cmdCtx) error
+}
+
+func (cmd command) checkArity(n int) bool {
+	if cmd.arity >= 0 {
+		return n == cmd.arity
+	}
+	return n >= -cmd.arity
+}
+
+// cmdCtx 命令执行上下文, 命令只在成功时写出回复
+type cmdCtx struct {
+	tx   *redka.Tx
+	args [][]byte
+	w    *respWriter
+}
+
+func (c *cmdCtx) str(i int) string {
+	return string(c.args[i])
+}
+
+func (c *cmdCtx) int(i int) (int, error) {
+	return parseInt(c.args[i])
+}
+
+func (c *cmdCtx) float(i int) (float64, error) {
+	return parseFloat(string(c.args[i]))
+}
+
+// strs 返回从 i 开始的全部参数
+func (c *cmdCtx) strs(i int) []string {
+	out := make([]string, 0, len(c.args)-i)
+	for _, arg := range c.args[i:] {
+		out = append(out, string(arg))
+	}
+	return out
+}
+
+// anys 返回从 i 开始的全部参数, 用于 redka 的 any 参数
+func (c *cmdCtx) anys(i int) []any {
+	out := make([]any, 0, len(c.args)-i)
+	for _, arg := range c.args[i:] {
+		out = append(out, arg)
+	}
+	return out
+}
+
+func parseInt(b []byte) (int, error) {
+	n, err := strconv.Atoi(string(b))
+	if err != nil {
+		return 0, errNotInt
+	}
+	return n, nil
+}
+
+// values 以数组回复
+func (c *cmdCtx) values(values []redka.Value) {
+	c.w.Array(len(values))
+	for _, v := range values {
+		c.w.Bulk(v)
+	}
+}
+
+// setValues 以集合回复
+func (c *cmdCtx) setValues(values []redka.Value) {
+	c.w.Set(len(values))
+	for _, v := range values {
+		c.w.Bulk(v)
+	}
+}
+
+// valueOrNull ErrNotFound 时回复空值
+func (c *cmdCtx) valueOrNull(v redka.Value, err error) error {
+	if errors.Is(err, redka.ErrNotFound) {
+		c.w.Null()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.w.Bulk(v)
+	return nil
+}
+
+// commands 支持的命令表
+var commands = map[string]command{
+	// key
+	"del":       {-2, false, cmdDel},
+	"unlink":    {-2, false, cmdDel},
+	"exists":    {-2, true, cmdExists},
+	"expire":    {3, false, cmdExpire(time.Second, false)},
+	"pexpire":   {3, false, cmdExpire(time.Millisecond, false)},
+	"expireat":  {3, false, cmdExpire(time.Second, true)},
+	"pexpireat": {3, false, cmdExpire(time.Millisecond, true)},
+	"persist":   {2, false, cmdPersist},
+	"ttl":       {2, true, cmdTTL(time.Second)},
+	"pttl":      {2, true, cmdTTL(time.Millisecond)},
+	"type":      {2, true, cmdType},
+	"keys":      {2, true, cmdKeys},
+	"scan":      {-2, true, cmdScan},
+	"rename":    {3, false, cmdRename},
+	"renamenx":  {3, false, cmdRenameNX},
+	"randomkey": {1, true, cmdRandomKey},
+	"dbsize":    {1, true, cmdDBSize},
+	"flushdb":   {-1, false, cmdFlush},
+	"flushall":  {-1, false, cmdFlush},
+
+	// string
+	"get":         {2, true, cmdGet},
+	"set":         {-3, false, cmdSet},
+	"setnx":       {3, false, cmdSetNX},
+	"setex":       {4, false, cmdSetEX(time.Second)},
+	"psetex":      {4, false, cmdSetEX(time.Millisecond)},
+	"getset":      {3, false, cmdGetSet},
+	"mget":        {-2, true, cmdMGet},
+	"mset":        {-3, false, cmdMSet},
+	"incr":        {2, false, cmdIncr(1, false)},
+	"decr":        {2, false, cmdIncr(-1, false)},
+	"incrby":      {3, false, cmdIncr(1, true)},
+	"decrby":      {3, false, cmdIncr(-1, true)},
+	"incrbyfloat": {3, false, cmdIncrFloat},
+	"strlen":      {2, true, cmdStrlen},
+	"append":      {3, false, cmdAppend},
+
+	// hash
+	"hget":         {3, true, cmdHGet},
+	"hset":         {-4, false, cmdHSet},
+	"hmset":        {-4, false, cmdHSet},
+	"hsetnx":       {4, false, cmdHSetNX},
+	"hmget":        {-3, true, cmdHMGet},
+	"hdel":         {-3, false, cmdHDel},
+	"hexists":      {3, true, cmdHExists},
+	"hgetall":      {2, true, cmdHGetAll},
+	"hkeys":        {2, true, cmdHKeys},
+	"hvals":        {2, true, cmdHVals},
+	"hlen":         {2, true, cmdHLen},
+	"hincrby":      {4, false, cmdHIncrBy},
+	"hincrbyfloat": {4, false, cmdHIncrByFloat},
+
+	// list
+	"lpush":     {-3, false, cmdPush(true)},
+	"rpush":     {-3, false, cmdPush(false)},
+	"lpop":      {-2, false, cmdPop(true)},
+	"rpop":      {-2, false, cmdPop(false)},
+	"llen":      {2, true, cmdLLen},
+	"lrange":    {4, true, cmdLRange},
+	"lindex":    {3, true, cmdLIndex},
+	"lset":      {4, false, cmdLSet},
+	"lrem":      {4, false, cmdLRem},
+	"ltrim":     {4, false, cmdLTrim},
+	"linsert":   {5, false, cmdLInsert},
+	"rpoplpush": {3, false, cmdRPopLPush},
+
+	// set
+	"sadd":        {-3, false, cmdSAdd},
+	"srem":        {-3, false, cmdSRem},
+	"smembers":    {2, true, cmdSMembers},
+	"sismember":   {3, true, cmdSIsMember},
+	"scard":       {2, true, cmdSCard},
+	"spop":        {2, false, cmdSPop},
+	"srandmember": {2, true, cmdSRandMember},
+	"smove":       {4, false, cmdSMove},
+	"sinter":      {-2, true, cmdSetOp("inter")},
+	"sunion":      {-2, true, cmdSetOp("union")},
+	"sdiff":       {-2, true, cmdSetOp("diff")},
+	"sinterstore": {-3, false, cmdSetOpStore("inter")},
+	"sunionstore": {-3, false, cmdSetOpStore("union")},
+	"sdiffstore":  {-3, false, cmdSetOpStore("diff")},
+
+	// sorted set
+	"zadd":             {-4, false, cmdZAdd},
+	"zrem":             {-3, false, cmdZRem},
+	"zscore":           {3, true, cmdZScore},
+	"zincrby":          {4, false, cmdZIncrBy},
+	"zcard":            {2, true, cmdZCard},
+	"zcount":           {4, true, cmdZCount},
+	"zrank":            {3, true, cmdZRank(false)},
+	"zrevrank":         {3, true, cmdZRank(true)},
+	"zrange":           {-4, true, cmdZRange},
+	"zrevrange":        {-4, true, cmdZRevRange},
+	"zrangebyscore":    {-4, true, cmdZRangeByScore(false)},
+	"zrevrangebyscore": {-4, true, cmdZRangeByScore(true)},
+	"zremrangebyrank":  {4, false, cmdZRemRangeByRank},
+	"zremrangebyscore": {4, false, cmdZRemRangeByScore},
+}
+
+// ---- key ----
+
+func cmdDel(c *cmdCtx) error {
+	n, err := c.tx.Key().Delete(c.strs(0)...)
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdExists(c *cmdCtx) error {
+	// 与 Redis 一致, 重复的键会重复计数
+	total := 0
+	for _, key := range c.strs(0) {
+		n, err := c.tx.Key().Count(key)
+		if err != nil {
+			return err
+		}
+		total += n
+	}
+	c.w.Int(total)
+	return nil
+}
+
+func cmdExpire(unit time.Duration, absolute bool) func(c *cmdCtx) error {
+	return func(c *cmdCtx) error {
+		n, err := c.int(1)
+		if err != nil {
+			return err
+		}
+		if absolute {
+			err = c.tx.Key().ExpireAt(c.str(0), time.UnixMilli(int64(time.Duration(n)*unit/time.Millisecond)))
+		} else {
+			err = c.tx.Key().Expire(c.str(0), time.Duration(n)*unit)
+		}
+		if errors.Is(err, redka.ErrNotFound) {
+			c.w.Int(0)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		c.w.Int(1)
+		return nil
+	}
+}
+
+func cmdPersist(c *cmdCtx) error {
+	key, err := c.tx.Key().Get(c.str(0))
+	if errors.Is(err, redka.ErrNotFound) || (err == nil && key.ETime == nil) {
+		c.w.Int(0)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.tx.Key().Persist(c.str(0)); err != nil {
+		return err
+	}
+	c.w.Int(1)
+	return nil
+}
+
+func cmdTTL(unit time.Duration) func(c *cmdCtx) error {
+	return func(c *cmdCtx) error {
+		key, err := c.tx.Key().Get(c.str(0))
+		if errors.Is(err, redka.ErrNotFound) {
+			c.w.Int(-2)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if key.ETime == nil {
+			c.w.Int(-1)
+			return nil
+		}
+		ttl := time.Until(time.UnixMilli(*key.ETime))
+		// 与 Redis 一致, 向上取整
+		c.w.Int(int((ttl + unit - 1) / unit))
+		return nil
+	}
+}
+
+func cmdType(c *cmdCtx) error {
+	key, err := c.tx.Key().Get(c.str(0))
+	if errors.Is(err, redka.ErrNotFound) {
+		c.w.Simple("none")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.w.Simple(key.TypeName())
+	return nil
+}
+
+func cmdKeys(c *cmdCtx) error {
+	keys, err := c.tx.Key().Keys(c.str(0))
+	if err != nil {
+		return err
+	}
+	c.w.Array(len(keys))
+	for _, key := range keys {
+		c.w.BulkString(key.Key)
+	}
+	return nil
+}
+
+func cmdScan(c *cmdCtx) error {
+	cursor, err := c.int(0)
+	if err != nil {
+		return errors.New("ERR invalid cursor")
+	}
+
+	pattern, count, ktype := "*", 0, redka.TypeAny
+	for i := 1; i < len(c.args); i += 2 {
+		if i+1 >= len(c.args) {
+			return errSyntax
+		}
+		switch strings.ToLower(c.str(i)) {
+		case "match":
+			pattern = c.str(i + 1)
+		case "count":
+			if count, err = c.int(i + 1); err != nil {
+				return err
+			}
+		case "type":
+			if ktype, err = parseType(c.str(i + 1)); err != nil {
+				return err
+			}
+		default:
+			return errSyntax
+		}
+	}
+
+	res, err := c.tx.Key().Scan(cursor, pattern, ktype, count)
+	if err != nil {
+		return err
+	}
+	c.w.Array(2)
+	c.w.BulkString(strconv.Itoa(res.Cursor))
+	var keys []string
+	for _, key := range res.Keys {
+		// redka 的 Scan 不按类型过滤, 这里补上
+		if ktype == redka.TypeAny || key.Type == ktype {
+			keys = append(keys, key.Key)
+		}
+	}
+	c.w.Array(len(keys))
+	for _, key := range keys {
+		c.w.BulkString(key)
+	}
+	return nil
+}
+
+func parseType(name string) (redka.TypeID, error) {
+	switch strings.ToLower(name) {
+	case "string":
+		return redka.TypeString, nil
+	case "list":
+		return redka.TypeList, nil
+	case "set":
+		return redka.TypeSet, nil
+	case "hash":
+		return redka.TypeHash, nil
+	case "zset":
+		return redka.TypeZSet, nil
+	}
+	return redka.TypeAny, errors.New("ERR unknown type name")
+}
+
+func cmdRename(c *cmdCtx) error {
+	err := c.tx.Key().Rename(c.str(0), c.str(1))
+	if errors.Is(err, redka.ErrNotFound) {
+		return errNoSuchKey
+	}
+	if err != nil {
+		return err
+	}
+	c.w.OK()
+	return nil
+}
+
+func cmdRenameNX(c *cmdCtx) error {
+	ok, err := c.tx.Key().RenameNotExists(c.str(0), c.str(1))
+	if errors.Is(err, redka.ErrNotFound) {
+		return errNoSuchKey
+	}
+	if err != nil {
+		return err
+	}
+	c.w.Bool(ok)
+	return nil
+}
+
+func cmdRandomKey(c *cmdCtx) error {
+	key, err := c.tx.Key().Random()
+	if errors.Is(err, redka.ErrNotFound) {
+		c.w.Null()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.w.BulkString(key.Key)
+	return nil
+}
+
+func cmdDBSize(c *cmdCtx) error {
+	n, err := c.tx.Key().Len()
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdFlush(c *cmdCtx) error {
+	// redka 的 DeleteAll 包含 VACUUM, 不能在事务中执行, 这里逐个删除
+	keys, err := c.tx.Key().Keys("*")
+	if err != nil {
+		return err
+	}
+	for len(keys) > 0 {
+		n := min(len(keys), scanBatch)
+		names := make([]string, n)
+		for i, key := range keys[:n] {
+			names[i] = key.Key
+		}
+		if _, err := c.tx.Key().Delete(names...); err != nil {
+			return err
+		}
+		keys = keys[n:]
+	}
+	c.w.OK()
+	return nil
+}
+
+// ---- string ----
+
+func cmdGet(c *cmdCtx) error {
+	return c.valueOrNull(c.getString(c.str(0)))
+}
+
+// getString 读取字符串, 键存在但不是字符串时返回 ErrKeyType
+func (c *cmdCtx) getString(key string) (redka.Value, error) {
+	v, err := c.tx.Str().Get(key)
+	if errors.Is(err, redka.ErrNotFound) {
+		if k, kerr := c.tx.Key().Get(key); kerr == nil && k.Type != redka.TypeString {
+			return nil, redka.ErrKeyType
+		}
+	}
+	return v, err
+}
+
+func cmdSet(c *cmdCtx) error {
+	key, value := c.str(0), c.args[1]
+	cmd := c.tx.Str().SetWith(key, value)
+	nx, xx, get := false, false, false
+
+	for i := 2; i < len(c.args); i++ {
+		opt := strings.ToLower(c.str(i))
+		switch opt {
+		case "nx":
+			nx = true
+			cmd = cmd.IfNotExists()
+		case "xx":
+			xx = true
+			cmd = cmd.IfExists()
+		case "get":
+			get = true
+		case "keepttl":
+			cmd = cmd.KeepTTL()
+		case "ex", "px", "exat", "pxat":
+			if i+1 >= len(c.args) {
+				return errSyntax
+			}
+			n, err := c.int(i + 1)
+			if err != nil {
+				return err
+			}
+			if n <= 0 {
+				return errors.New("ERR invalid expire time in 'set' command")
+			}
+			i++
+			switch opt {
+			case "ex":
+				cmd = cmd.TTL(time.Duration(n) * time.Second)
+			case "px":
+				cmd = cmd.TTL(time.Duration(n) * time.Millisecond)
+			case "exat":
+				cmd = cmd.At(time.Unix(int64(n), 0))
+			case "pxat":
+				cmd = cmd.At(time.UnixMilli(int64(n)))
+			}
+		default:
+			return errSyntax
+		}
+	}
+	if nx && xx {
+		return errSyntax
+	}
+
+	var prev redka.Value
+	if get {
+		v, err := c.getString(key)
+		if err != nil && !errors.Is(err, redka.ErrNotFound) {
+			return err
+		}
+		prev = v
+	}
+
+	out, err := cmd.Run()
+	if err != nil {
+		return err
+	}
+	switch {
+	case get && prev == nil:
+		c.w.Null()
+	case get:
+		c.w.Bulk(prev)
+	case !out.Created && !out.Updated:
+		c.w.Null()
+	default:
+		c.w.OK()
+	}
+	return nil
+}
+
+func cmdSetNX(c *cmdCtx) error {
+	out, err := c.tx.Str().SetWith(c.str(0), c.args[1]).IfNotExists().Run()
+	if err != nil {
+		return err
+	}
+	c.w.Bool(out.Created)
+	return nil
+}
+
+func cmdSetEX(unit time.Duration) func(c *cmdCtx) error {
+	return func(c *cmdCtx) error {
+		n, err := c.int(1)
+		if err != nil {
+			return err
+		}
+		if n <= 0 {
+			return errors.New("ERR invalid expire time")
+		}
+		if err := c.tx.Str().SetExpires(c.str(0), c.args[2], time.Duration(n)*unit); err != nil {
+			return err
+		}
+		c.w.OK()
+		return nil
+	}
+}
+
+func cmdGetSet(c *cmdCtx) error {
+	prev, err := c.getString(c.str(0))
+	if err != nil && !errors.Is(err, redka.ErrNotFound) {
+		return err
+	}
+	if err := c.tx.Str().Set(c.str(0), c.args[1]); err != nil {
+		return err
+	}
+	if prev == nil {
+		c.w.Null()
+	} else {
+		c.w.Bulk(prev)
+	}
+	return nil
+}
+
+func cmdMGet(c *cmdCtx) error {
+	keys := c.strs(0)
+	items, err := c.tx.Str().GetMany(keys...)
+	if err != nil {
+		return err
+	}
+	c.w.Array(len(keys))
+	for _, key := range keys {
+		if v, ok := items[key]; ok {
+			c.w.Bulk(v)
+		} else {
+			c.w.Null()
+		}
+	}
+	return nil
+}
+
+func cmdMSet(c *cmdCtx) error {
+	if len(c.args)%2 != 0 {
+		return errors.New("ERR wrong number of arguments for 'mset' command")
+	}
+	items := make(map[string]any, len(c.args)/2)
+	for i := 0; i < len(c.args); i += 2 {
+		items[c.str(i)] = c.args[i+1]
+	}
+	if err := c.tx.Str().SetMany(items); err != nil {
+		return err
+	}
+	c.w.OK()
+	return nil
+}
+
+func cmdIncr(sign int, withDelta bool) func(c *cmdCtx) error {
+	return func(c *cmdCtx) error {
+		delta := 1
+		if withDelta {
+			n, err := c.int(1)
+			if err != nil {
+				return err
+			}
+			delta = n
+		}
+		n, err := c.tx.Str().Incr(c.str(0), sign*delta)
+		if err != nil {
+			return err
+		}
+		c.w.Int(n)
+		return nil
+	}
+}
+
+func cmdIncrFloat(c *cmdCtx) error {
+	delta, err := c.float(1)
+	if err != nil {
+		return err
+	}
+	n, err := c.tx.Str().IncrFloat(c.str(0), delta)
+	if err != nil {
+		return err
+	}
+	c.w.BulkString(formatFloat(n))
+	return nil
+}
+
+func cmdStrlen(c *cmdCtx) error {
+	v, err := c.getString(c.str(0))
+	if err != nil && !errors.Is(err, redka.ErrNotFound) {
+		return err
+	}
+	c.w.Int(len(v))
+	return nil
+}
+
+func cmdAppend(c *cmdCtx) error {
+	v, err := c.getString(c.str(0))
+	if err != nil && !errors.Is(err, redka.ErrNotFound) {
+		return err
+	}
+	value := append(append([]byte{}, v...), c.args[1]...)
+	if _, err := c.tx.Str().SetWith(c.str(0), value).KeepTTL().Run(); err != nil {
+		return err
+	}
+	c.w.Int(len(value))
+	return nil
+}
+
+// ---- hash ----
+
+func cmdHGet(c *cmdCtx) error {
+	return c.valueOrNull(c.tx.Hash().Get(c.str(0), c.str(1)))
+}
+
+func cmdHSet(c *cmdCtx) error {
+	if len(c.args)%2 != 1 {
+		return errors.New("ERR wrong number of arguments for 'hset' command")
+	}
+	items := make(map[string]any, len(c.args)/2)
+	for i := 1; i < len(c.args); i += 2 {
+		items[c.str(i)] = c.args[i+1]
+	}
+	n, err := c.tx.Hash().SetMany(c.str(0), items)
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdHSetNX(c *cmdCtx) error {
+	ok, err := c.tx.Hash().SetNotExists(c.str(0), c.str(1), c.args[2])
+	if err != nil {
+		return err
+	}
+	c.w.Bool(ok)
+	return nil
+}
+
+func cmdHMGet(c *cmdCtx) error {
+	fields := c.strs(1)
+	items, err := c.tx.Hash().GetMany(c.str(0), fields...)
+	if err != nil {
+		return err
+	}
+	c.w.Array(len(fields))
+	for _, field := range fields {
+		if v, ok := items[field]; ok {
+			c.w.Bulk(v)
+		} else {
+			c.w.Null()
+		}
+	}
+	return nil
+}
+
+func cmdHDel(c *cmdCtx) error {
+	n, err := c.tx.Hash().Delete(c.str(0), c.strs(1)...)
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdHExists(c *cmdCtx) error {
+	ok, err := c.tx.Hash().Exists(c.str(0), c.str(1))
+	if err != nil {
+		return err
+	}
+	c.w.Bool(ok)
+	return nil
+}
+
+func cmdHGetAll(c *cmdCtx) error {
+	items, err := c.tx.Hash().Items(c.str(0))
+	if err != nil {
+		return err
+	}
+	c.w.Map(len(items))
+	for field, v := range items {
+		c.w.BulkString(field)
+		c.w.Bulk(v)
+	}
+	return nil
+}
+
+func cmdHKeys(c *cmdCtx) error {
+	fields, err := c.tx.Hash().Fields(c.str(0))
+	if err != nil {
+		return err
+	}
+	c.w.Array(len(fields))
+	for _, field := range fields {
+		c.w.BulkString(field)
+	}
+	return nil
+}
+
+func cmdHVals(c *cmdCtx) error {
+	values, err := c.tx.Hash().Values(c.str(0))
+	if err != nil {
+		return err
+	}
+	c.values(values)
+	return nil
+}
+
+func cmdHLen(c *cmdCtx) error {
+	n, err := c.tx.Hash().Len(c.str(0))
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdHIncrBy(c *cmdCtx) error {
+	delta, err := c.int(2)
+	if err != nil {
+		return err
+	}
+	n, err := c.tx.Hash().Incr(c.str(0), c.str(1), delta)
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdHIncrByFloat(c *cmdCtx) error {
+	delta, err := c.float(2)
+	if err != nil {
+		return err
+	}
+	n, err := c.tx.Hash().IncrFloat(c.str(0), c.str(1), delta)
+	if err != nil {
+		return err
+	}
+	c.w.BulkString(formatFloat(n))
+	return nil
+}
+
+// ---- list ----
+
+func cmdPush(front bool) func(c *cmdCtx) error {
+	return func(c *cmdCtx) error {
+		var (
+			n   int
+			err error
+		)
+		for _, elem := range c.args[1:] {
+			if front {
+				n, err = c.tx.List().PushFront(c.str(0), elem)
+			} else {
+				n, err = c.tx.List().PushBack(c.str(0), elem)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		c.w.Int(n)
+		return nil
+	}
+}
+
+func cmdPop(front bool) func(c *cmdCtx) error {
+	return func(c *cmdCtx) error {
+		pop := c.tx.List().PopBack
+		if front {
+			pop = c.tx.List().PopFront
+		}
+		if len(c.args) == 1 {
+			return c.valueOrNull(pop(c.str(0)))
+		}
+
+		count, err := c.int(1)
+		if err != nil || count < 0 {
+			return errors.New("ERR value is out of range, must be positive")
+		}
+		var values []redka.Value
+		for i := 0; i < count; i++ {
+			v, err := pop(c.str(0))
+			if errors.Is(err, redka.ErrNotFound) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			values = append(values, v)
+		}
+		if len(values) == 0 {
+			c.w.NullArray()
+			return nil
+		}
+		c.values(values)
+		return nil
+	}
+}
+
+func cmdLLen(c *cmdCtx) error {
+	n, err := c.tx.List().Len(c.str(0))
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdLRange(c *cmdCtx) error {
+	start, err := c.int(1)
+	if err != nil {
+		return err
+	}
+	stop, err := c.int(2)
+	if err != nil {
+		return err
+	}
+	values, err := c.tx.List().Range(c.str(0), start, stop)
+	if err != nil {
+		return err
+	}
+	c.values(values)
+	return nil
+}
+
+func cmdLIndex(c *cmdCtx) error {
+	idx, err := c.int(1)
+	if err != nil {
+		return err
+	}
+	return c.valueOrNull(c.tx.List().Get(c.str(0), idx))
+}
+
+func cmdLSet(c *cmdCtx) error {
+	idx, err := c.int(1)
+	if err != nil {
+		return err
+	}
+	err = c.tx.List().Set(c.str(0), idx, c.args[2])
+	if errors.Is(err, redka.ErrNotFound) {
+		if n, _ := c.tx.List().Len(c.str(0)); n == 0 {
+			return errNoSuchKey
+		}
+		return errIndexRange
+	}
+	if err != nil {
+		return err
+	}
+	c.w.OK()
+	return nil
+}
+
+func cmdLRem(c *cmdCtx) error {
+	count, err := c.int(1)
+	if err != nil {
+		return err
+	}
+	var n int
+	switch {
+	case count > 0:
+		n, err = c.tx.List().DeleteFront(c.str(0), c.args[2], count)
+	case count < 0:
+		n, err = c.tx.List().DeleteBack(c.str(0), c.args[2], -count)
+	default:
+		n, err = c.tx.List().Delete(c.str(0), c.args[2])
+	}
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdLTrim(c *cmdCtx) error {
+	start, err := c.int(1)
+	if err != nil {
+		return err
+	}
+	stop, err := c.int(2)
+	if err != nil {
+		return err
+	}
+	if _, err := c.tx.List().Trim(c.str(0), start, stop); err != nil {
+		return err
+	}
+	c.w.OK()
+	return nil
+}
+
+func cmdLInsert(c *cmdCtx) error {
+	var (
+		n   int
+		err error
+	)
+	switch strings.ToLower(c.str(1)) {
+	case "before":
+		n, err = c.tx.List().InsertBefore(c.str(0), c.args[2], c.args[3])
+	case "after":
+		n, err = c.tx.List().InsertAfter(c.str(0), c.args[2], c.args[3])
+	default:
+		return errSyntax
+	}
+	if err != nil && !errors.Is(err, redka.ErrNotFound) {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdRPopLPush(c *cmdCtx) error {
+	return c.valueOrNull(c.tx.List().PopBackPushFront(c.str(0), c.str(1)))
+}
+
+// ---- set ----
+
+func cmdSAdd(c *cmdCtx) error {
+	n, err := c.tx.Set().Add(c.str(0), c.anys(1)...)
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdSRem(c *cmdCtx) error {
+	n, err := c.tx.Set().Delete(c.str(0), c.anys(1)...)
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdSMembers(c *cmdCtx) error {
+	values, err := c.tx.Set().Items(c.str(0))
+	if err != nil {
+		return err
+	}
+	c.setValues(values)
+	return nil
+}
+
+func cmdSIsMember(c *cmdCtx) error {
+	ok, err := c.tx.Set().Exists(c.str(0), c.args[1])
+	if err != nil {
+		return err
+	}
+	c.w.Bool(ok)
+	return nil
+}
+
+func cmdSCard(c *cmdCtx) error {
+	n, err := c.tx.Set().Len(c.str(0))
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdSPop(c *cmdCtx) error {
+	return c.valueOrNull(c.tx.Set().Pop(c.str(0)))
+}
+
+func cmdSRandMember(c *cmdCtx) error {
+	return c.valueOrNull(c.tx.Set().Random(c.str(0)))
+}
+
+func cmdSMove(c *cmdCtx) error {
+	err := c.tx.Set().Move(c.str(0), c.str(1), c.args[2])
+	if errors.Is(err, redka.ErrNotFound) {
+		c.w.Int(0)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.w.Int(1)
+	return nil
+}
+
+func cmdSetOp(op string) func(c *cmdCtx) error {
+	return func(c *cmdCtx) error {
+		var (
+			values []redka.Value
+			err    error
+		)
+		keys := c.strs(0)
+		switch op {
+		case "inter":
+			values, err = c.tx.Set().Inter(keys...)
+		case "union":
+			values, err = c.tx.Set().Union(keys...)
+		default:
+			values, err = c.tx.Set().Diff(keys...)
+		}
+		if err != nil {
+			return err
+		}
+		c.setValues(values)
+		return nil
+	}
+}
+
+func cmdSetOpStore(op string) func(c *cmdCtx) error {
+	return func(c *cmdCtx) error {
+		var (
+			n   int
+			err error
+		)
+		dest, keys := c.str(0), c.strs(1)
+		switch op {
+		case "inter":
+			n, err = c.tx.Set().InterStore(dest, keys...)
+		case "union":
+			n, err = c.tx.Set().UnionStore(dest, keys...)
+		default:
+			n, err = c.tx.Set().DiffStore(dest, keys...)
+		}
+		if err != nil {
+			return err
+		}
+		c.w.Int(n)
+		return nil
+	}
+}
+
+// ---- sorted set ----
+
+func cmdZAdd(c *cmdCtx) error {
+	key := c.str(0)
+	nx, xx, ch := false, false, false
+
+	i := 1
+	for ; i < len(c.args); i++ {
+		switch strings.ToLower(c.str(i)) {
+		case "nx":
+			nx = true
+			continue
+		case "xx":
+			xx = true
+			continue
+		case "ch":
+			ch = true
+			continue
+		}
+		break
+	}
+	rest := c.args[i:]
+	if (nx && xx) || len(rest) == 0 || len(rest)%2 != 0 {
+		return errSyntax
+	}
+
+	type pair struct {
+		score float64
+		elem  []byte
+	}
+	pairs := make([]pair, 0, len(rest)/2)
+	for j := 0; j < len(rest); j += 2 {
+		score, err := parseFloat(string(rest[j]))
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, pair{score, rest[j+1]})
+	}
+
+	added, changed := 0, 0
+	for _, p := range pairs {
+		old, err := c.tx.ZSet().GetScore(key, p.elem)
+		exists := err == nil
+		if err != nil && !errors.Is(err, redka.ErrNotFound) {
+			return err
+		}
+		if (nx && exists) || (xx && !exists) {
+			continue
+		}
+		if _, err := c.tx.ZSet().Add(key, p.elem, p.score); err != nil {
+			return err
+		}
+		if !exists {
+			added++
+			changed++
+		} else if old != p.score {
+			changed++
+		}
+	}
+
+	if ch {
+		c.w.Int(changed)
+	} else {
+		c.w.Int(added)
+	}
+	return nil
+}
+
+func cmdZRem(c *cmdCtx) error {
+	n, err := c.tx.ZSet().Delete(c.str(0), c.anys(1)...)
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdZScore(c *cmdCtx) error {
+	score, err := c.tx.ZSet().GetScore(c.str(0), c.args[1])
+	if errors.Is(err, redka.ErrNotFound) {
+		c.w.Null()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.w.Double(score)
+	return nil
+}
+
+func cmdZIncrBy(c *cmdCtx) error {
+	delta, err := c.float(1)
+	if err != nil {
+		return err
+	}
+	score, err := c.tx.ZSet().Incr(c.str(0), c.args[2], delta)
+	if err != nil {
+		return err
+	}
+	c.w.Double(score)
+	return nil
+}
+
+func cmdZCard(c *cmdCtx) error {
+	n, err := c.tx.ZSet().Len(c.str(0))
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdZCount(c *cmdCtx) error {
+	min, err := parseMin(c.str(1))
+	if err != nil {
+		return err
+	}
+	max, err := parseMax(c.str(2))
+	if err != nil {
+		return err
+	}
+	n, err := c.tx.ZSet().Count(c.str(0), min, max)
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+func cmdZRank(rev bool) func(c *cmdCtx) error {
+	return func(c *cmdCtx) error {
+		var (
+			rank int
+			err  error
+		)
+		if rev {
+			rank, _, err = c.tx.ZSet().GetRankRev(c.str(0), c.args[1])
+		} else {
+			rank, _, err = c.tx.ZSet().GetRank(c.str(0), c.args[1])
+		}
+		if errors.Is(err, redka.ErrNotFound) {
+			c.w.Null()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		c.w.Int(rank)
+		return nil
+	}
+}
+
+// zrangeArgs ZRANGE 系列命令的参数
+type zrangeArgs struct {
+	byScore    bool
+	rev        bool
+	withScores bool
+	offset     int
+	count      int
+}
+
+func cmdZRange(c *cmdCtx) error {
+	args := zrangeArgs{}
+	for i := 3; i < len(c.args); i++ {
+		switch strings.ToLower(c.str(i)) {
+		case "byscore":
+			args.byScore = true
+		case "rev":
+			args.rev = true
+		case "withscores":
+			args.withScores = true
+		case "limit":
+			if i+2 >= len(c.args) {
+				return errSyntax
+			}
+			var err error
+			if args.offset, err = c.int(i + 1); err != nil {
+				return err
+			}
+			if args.count, err = c.int(i + 2); err != nil {
+				return err
+			}
+			i += 2
+		default:
+			return errSyntax
+		}
+	}
+	return c.zrange(c.str(1), c.str(2), args)
+}
+
+func cmdZRevRange(c *cmdCtx) error {
+	args := zrangeArgs{rev: true}
+	for i := 3; i < len(c.args); i++ {
+		if strings.ToLower(c.str(i)) != "withscores" {
+			return errSyntax
+		}
+		args.withScores = true
+	}
+	return c.zrange(c.str(1), c.str(2), args)
+}
+
+func cmdZRangeByScore(rev bool) func(c *cmdCtx) error {
+	return func(c *cmdCtx) error {
+		args := zrangeArgs{byScore: true, rev: rev}
+		for i := 3; i < len(c.args); i++ {
+			switch strings.ToLower(c.str(i)) {
+			case "withscores":
+				args.withScores = true
+			case "limit":
+				if i+2 >= len(c.args) {
+					return errSyntax
+				}
+				var err error
+				if args.offset, err = c.int(i + 1); err != nil {
+					return err
+				}
+				if args.count, err = c.int(i + 2); err != nil {
+					return err
+				}
+				i += 2
+			default:
+				return errSyntax
+			}
+		}
+		return c.zrange(c.str(1), c.str(2), args)
+	}
+}
+
+// zrange 执行有序集合范围查询, 与 Redis 一致, BYSCORE 且 REV 时参数顺序为 max min
+func (c *cmdCtx) zrange(start, stop string, args zrangeArgs) error {
+	key := c.str(0)
+	cmd := c.tx.ZSet().RangeWith(key)
+	if args.rev {
+		cmd = cmd.Desc()
+	}
+
+	if args.byScore {
+		if args.rev {
+			start, stop = stop, start
+		}
+		min, err := parseMin(start)
+		if err != nil {
+			return err
+		}
+		max, err := parseMax(stop)
+		if err != nil {
+			return err
+		}
+		cmd = cmd.ByScore(min, max)
+		if args.offset > 0 {
+			cmd = cmd.Offset(args.offset)
+		}
+		if args.count > 0 {
+			cmd = cmd.Count(args.count)
+		}
+	} else {
+		lo, err := parseInt([]byte(start))
+		if err != nil {
+			return err
+		}
+		hi, err := parseInt([]byte(stop))
+		if err != nil {
+			return err
+		}
+		n, err := c.tx.ZSet().Len(key)
+		if err != nil {
+			return err
+		}
+		lo, hi, ok := normalizeRange(lo, hi, n)
+		if !ok {
+			c.w.Array(0)
+			return nil
+		}
+		cmd = cmd.ByRank(lo, hi)
+	}
+
+	items, err := cmd.Run()
+	if err != nil {
+		return err
+	}
+
+	if args.withScores && c.w.proto >= 3 {
+		// RESP3 下每个元素是 [member, score] 二元组
+		c.w.Array(len(items))
+		for _, item := range items {
+			c.w.Array(2)
+			c.w.Bulk(item.Elem)
+			c.w.Double(item.Score)
+		}
+		return nil
+	}
+	if args.withScores {
+		c.w.Array(len(items) * 2)
+	} else {
+		c.w.Array(len(items))
+	}
+	for _, item := range items {
+		c.w.Bulk(item.Elem)
+		if args.withScores {
+			c.w.Double(item.Score)
+		}
+	}
+	return nil
+}
+
+func cmdZRemRangeByRank(c *cmdCtx) error {
+	lo, err := c.int(1)
+	if err != nil {
+		return err
+	}
+	hi, err := c.int(2)
+	if err != nil {
+		return err
+	}
+	n, err := c.tx.ZSet().Len(c.str(0))
+	if err != nil {
+		return err
+	}
+	lo, hi, ok := normalizeRange(lo, hi, n)
+	if !ok {
+		c.w.Int(0)
+		return nil
+	}
+	deleted, err := c.tx.ZSet().DeleteWith(c.str(0)).ByRank(lo, hi).Run()
+	if err != nil {
+		return err
+	}
+	c.w.Int(deleted)
+	return nil
+}
+
+func cmdZRemRangeByScore(c *cmdCtx) error {
+	min, err := parseMin(c.str(1))
+	if err != nil {
+		return err
+	}
+	max, err := parseMax(c.str(2))
+	if err != nil {
+		return err
+	}
+	n, err := c.tx.ZSet().DeleteWith(c.str(0)).ByScore(min, max).Run()
+	if err != nil {
+		return err
+	}
+	c.w.Int(n)
+	return nil
+}
+
+// parseMin 解析分数区间下界, 支持 ( 开区间和 ±inf
+func parseMin(s string) (float64, error) {
+	return parseBound(s, math.Inf(1))
+}
+
+// parseMax 解析分数区间上界, 支持 ( 开区间和 ±inf
+func parseMax(s string) (float64, error) {
+	return parseBound(s, math.Inf(-1))
+}
+
+// parseBound 开区间用朝 toward 方向相邻的浮点数表示
+func parseBound(s string, toward float64) (float64, error) {
+	exclusive := strings.HasPrefix(s, "(")
+	f, err := parseFloat(strings.TrimPrefix(s, "("))
+	if err != nil {
+		return 0, errors.New("ERR min or max is not a float")
+	}
+	if exclusive {
+		f = math.Nextafter(f, toward)
+	}
+	return f, nil
+}
+
+// normalizeRange 将 Redis 风格的下标(支持负数)转换为 [lo, hi] 闭区间
+func normalizeRange(lo, hi, n int) (int, int, bool) {
+	if lo < 0 {
+		lo += n
+	}
+	if hi < 0 {
+		hi += n
+	}
+	if lo < 0 {
+		lo = 0
+	}
+	if hi >= n {
+		hi = n - 1
+	}
+	if lo > hi || n == 0 {
+		return 0, 0, false
+	}
+	return lo, hi, true
+}
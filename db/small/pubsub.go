@@ -0,0 +1,315 @@
+package small
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// 键空间通知的频道前缀, 与 Redis 的 notify-keyspace-events 格式一致
+const (
+	KeyspacePrefix = "__keyspace@0__:"
+	KeyeventPrefix = "__keyevent@0__:"
+)
+
+// 键空间事件
+const (
+	EventSet    = "set"
+	EventDel    = "del"
+	EventExpire = "expire"
+)
+
+// DropPolicy 订阅者缓冲区满时的处理策略
+type DropPolicy int
+
+const (
+	DropNewest DropPolicy = iota // 丢弃新消息
+	DropOldest                   // 丢弃缓冲区中最旧的消息
+	Block                        // 阻塞发布者直到有空间或取消订阅
+)
+
+// Message 订阅收到的消息
+type Message struct {
+	Channel string // 消息所在频道
+	Pattern string // 匹配的模式, 频道订阅时为空
+	Payload []byte
+}
+
+// BrokerOption 发布订阅选项
+type BrokerOption func(*Broker)
+
+// WithBufferSize 设置每个订阅者的缓冲区大小
+func WithBufferSize(size int) BrokerOption {
+	return func(b *Broker) {
+		b.bufSize = size
+	}
+}
+
+// WithDropPolicy 设置缓冲区满时的处理策略
+func WithDropPolicy(policy DropPolicy) BrokerOption {
+	return func(b *Broker) {
+		b.policy = policy
+	}
+}
+
+// Broker 进程内发布订阅
+type Broker struct {
+	mu      sync.RWMutex
+	subs    map[*Subscription]struct{}
+	bufSize int
+	policy  DropPolicy
+}
+
+// NewBroker 创建发布订阅
+func NewBroker(opts ...BrokerOption) *Broker {
+	b := &Broker{
+		subs:    make(map[*Subscription]struct{}),
+		bufSize: 64,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Subscribe 订阅频道, ctx 取消时自动退订
+func (b *Broker) Subscribe(ctx context.Context, channels ...string) *Subscription {
+	return b.subscribe(ctx, channels, false)
+}
+
+// PSubscribe 按 glob 模式订阅频道, 支持 * ? [] 和 \ 转义
+func (b *Broker) PSubscribe(ctx context.Context, patterns ...string) *Subscription {
+	return b.subscribe(ctx, patterns, true)
+}
+
+func (b *Broker) subscribe(ctx context.Context, topics []string, pattern bool) *Subscription {
+	ch := make(chan Message, b.bufSize)
+	s := &Subscription{
+		C:       ch,
+		ch:      ch,
+		done:    make(chan struct{}),
+		broker:  b,
+		topics:  topics,
+		pattern: pattern,
+	}
+
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Unsubscribe()
+		case <-s.done:
+		}
+	}()
+	return s
+}
+
+// Publish 发布消息, 返回接收到消息的订阅者数量
+func (b *Broker) Publish(channel string, payload []byte) int {
+	b.mu.RLock()
+	subs := make([]*Subscription, 0, len(b.subs))
+	for s := range b.subs {
+		subs = append(subs, s)
+	}
+	b.mu.RUnlock()
+
+	count := 0
+	for _, s := range subs {
+		pattern, ok := s.match(channel)
+		if !ok {
+			continue
+		}
+		msg := Message{Channel: channel, Pattern: pattern, Payload: payload}
+		if s.deliver(msg, b.policy) {
+			count++
+		}
+	}
+	return count
+}
+
+// notify 发送键空间通知
+func (b *Broker) notify(event, key string) {
+	if b == nil {
+		return
+	}
+	b.Publish(KeyspacePrefix+key, []byte(event))
+	b.Publish(KeyeventPrefix+event, []byte(key))
+}
+
+// Subscription 订阅
+type Subscription struct {
+	C <-chan Message
+
+	ch      chan Message
+	mu      sync.RWMutex
+	closed  bool
+	once    sync.Once
+	done    chan struct{}
+	dropped atomic.Uint64
+	broker  *Broker
+	topics  []string
+	pattern bool
+}
+
+// Unsubscribe 退订并关闭 C, 可重复调用
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.broker.mu.Lock()
+		delete(s.broker.subs, s)
+		s.broker.mu.Unlock()
+
+		// 先通知阻塞中的发布者退出, 再关闭通道
+		close(s.done)
+		s.mu.Lock()
+		s.closed = true
+		close(s.ch)
+		s.mu.Unlock()
+	})
+}
+
+// Dropped 返回因缓冲区满被丢弃的消息数
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// match 判断频道是否匹配, 返回匹配的模式
+func (s *Subscription) match(channel string) (string, bool) {
+	for _, topic := range s.topics {
+		if !s.pattern {
+			if topic == channel {
+				return "", true
+			}
+			continue
+		}
+		if MatchGlob(topic, channel) {
+			return topic, true
+		}
+	}
+	return "", false
+}
+
+// deliver 按策略投递消息
+func (s *Subscription) deliver(msg Message, policy DropPolicy) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return false
+	}
+
+	select {
+	case s.ch <- msg:
+		return true
+	default:
+	}
+
+	switch policy {
+	case Block:
+		select {
+		case s.ch <- msg:
+			return true
+		case <-s.done:
+			return false
+		}
+	case DropOldest:
+		if cap(s.ch) > 0 {
+			select {
+			case <-s.ch:
+				s.dropped.Add(1)
+			default:
+			}
+			select {
+			case s.ch <- msg:
+				return true
+			default:
+			}
+		}
+	}
+	s.dropped.Add(1)
+	return false
+}
+
+// MatchGlob Redis 风格的 glob 匹配, 支持 * ? [] 和 \ 转义
+func MatchGlob(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if MatchGlob(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end, ok := matchClass(pattern, s[0])
+			if !ok {
+				return false
+			}
+			pattern = pattern[end:]
+			s = s[1:]
+			continue
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		s = s[1:]
+	}
+	return len(s) == 0
+}
+
+// matchClass 匹配 [...] 字符类, 返回字符类之后的位置
+func matchClass(pattern string, c byte) (int, bool) {
+	i := 1
+	negate := false
+	if i < len(pattern) && pattern[i] == '^' {
+		negate = true
+		i++
+	}
+
+	matched := false
+	for ; i < len(pattern) && pattern[i] != ']'; i++ {
+		lo := pattern[i]
+		if lo == '\\' && i+1 < len(pattern) {
+			i++
+			lo = pattern[i]
+		}
+		hi := lo
+		if i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']' {
+			hi = pattern[i+2]
+			i += 2
+		}
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+	}
+	if i >= len(pattern) {
+		// 没有闭合的 ], 按字面量处理
+		return 1, c == '['
+	}
+	return i + 1, matched != negate
+}
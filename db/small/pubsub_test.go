@@ -0,0 +1,159 @@
+package small
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, sub *Subscription) Message {
+	t.Helper()
+	select {
+	case msg := <-sub.C:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for message")
+	}
+	return Message{}
+}
+
+func TestBrokerSubscribe(t *testing.T) {
+	b := NewBroker()
+	ctx := context.Background()
+
+	sub := b.Subscribe(ctx, "news")
+	psub := b.PSubscribe(ctx, "n[aeiou]ws", "sport.*")
+	defer sub.Unsubscribe()
+	defer psub.Unsubscribe()
+
+	if n := b.Publish("news", []byte("hello")); n != 2 {
+		t.Errorf("expected 2 receivers, got %d", n)
+	}
+	if msg := receive(t, sub); msg.Channel != "news" || string(msg.Payload) != "hello" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	if msg := receive(t, psub); msg.Pattern != "n[aeiou]ws" {
+		t.Errorf("unexpected pattern: %+v", msg)
+	}
+
+	if n := b.Publish("sport.football", nil); n != 1 {
+		t.Errorf("expected 1 receiver, got %d", n)
+	}
+	if n := b.Publish("weather", nil); n != 0 {
+		t.Errorf("expected 0 receivers, got %d", n)
+	}
+}
+
+func TestBrokerContextCancel(t *testing.T) {
+	b := NewBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := b.Subscribe(ctx, "news")
+
+	cancel()
+	select {
+	case _, ok := <-sub.C:
+		if ok {
+			t.Error("expected closed channel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription was not closed")
+	}
+	if n := b.Publish("news", nil); n != 0 {
+		t.Errorf("expected 0 receivers, got %d", n)
+	}
+}
+
+func TestBrokerDropPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		policy DropPolicy
+		want   string
+	}{
+		{DropNewest, "1"},
+		{DropOldest, "3"},
+	} {
+		b := NewBroker(WithBufferSize(1), WithDropPolicy(tt.policy))
+		sub := b.Subscribe(context.Background(), "ch")
+
+		b.Publish("ch", []byte("1"))
+		b.Publish("ch", []byte("2"))
+		b.Publish("ch", []byte("3"))
+
+		if msg := receive(t, sub); string(msg.Payload) != tt.want {
+			t.Errorf("policy %d: expected %s, got %s", tt.policy, tt.want, msg.Payload)
+		}
+		if sub.Dropped() != 2 {
+			t.Errorf("policy %d: expected 2 dropped, got %d", tt.policy, sub.Dropped())
+		}
+		sub.Unsubscribe()
+	}
+}
+
+func TestBrokerBlockUnsubscribe(t *testing.T) {
+	b := NewBroker(WithBufferSize(0), WithDropPolicy(Block))
+	sub := b.Subscribe(context.Background(), "ch")
+
+	done := make(chan int)
+	go func() { done <- b.Publish("ch", nil) }()
+
+	time.Sleep(10 * time.Millisecond)
+	sub.Unsubscribe()
+	select {
+	case n := <-done:
+		if n != 0 {
+			t.Errorf("expected 0 receivers, got %d", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("publisher still blocked after unsubscribe")
+	}
+}
+
+func TestStoreKeyspaceNotify(t *testing.T) {
+	b := NewBroker()
+	store := NewStore[user](newTestDB(t), WithPrefix("user:"), WithNotifier(b))
+
+	keyspace := b.PSubscribe(context.Background(), KeyspacePrefix+"user:*")
+	keyevent := b.Subscribe(context.Background(), KeyeventPrefix+EventDel)
+	defer keyspace.Unsubscribe()
+	defer keyevent.Unsubscribe()
+
+	store.Set("1", user{Name: "alice"})
+	store.Expire("1", time.Hour)
+	store.Delete("1", "2")
+
+	for _, event := range []string{EventSet, EventExpire, EventDel} {
+		msg := receive(t, keyspace)
+		if msg.Channel != KeyspacePrefix+"user:1" || string(msg.Payload) != event {
+			t.Errorf("expected %s on user:1, got %+v", event, msg)
+		}
+	}
+	if msg := receive(t, keyevent); string(msg.Payload) != "user:1" {
+		t.Errorf("unexpected keyevent: %+v", msg)
+	}
+	select {
+	case msg := <-keyevent.C:
+		t.Errorf("unexpected extra event: %+v", msg)
+	default:
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "", true},
+		{"h?llo", "hello", true},
+		{"h*llo", "heeeello", true},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"user:*", "user:1/2", true},
+		{"a*b", "acd", false},
+	} {
+		if got := MatchGlob(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v", tt.pattern, tt.s, got)
+		}
+	}
+}
@@ -0,0 +1,586 @@
+package small
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/nalgeon/redka"
+)
+
+// JobState 任务状态
+type JobState string
+
+const (
+	StateScheduled JobState = "scheduled" // 延迟或等待重试
+	StateReady     JobState = "ready"     // 等待执行
+	StateActive    JobState = "active"    // 执行中
+	StateDead      JobState = "dead"      // 超过重试次数, 进入死信
+)
+
+// 每次维护(延迟转就绪/超时回收)最多处理的任务数
+const maintainBatch = 100
+
+var (
+	// ErrJobLost 任务已超时被重新投递或已被删除
+	ErrJobLost = errors.New("job is no longer active")
+	// ErrJobState 当前状态不支持该操作
+	ErrJobState = errors.New("job state does not allow this operation")
+)
+
+// Job 队列任务
+type Job struct {
+	ID         string    `json:"id"`
+	Queue      string    `json:"queue"`
+	Payload    []byte    `json:"payload"`
+	Priority   int       `json:"priority"`
+	State      JobState  `json:"state"`
+	Attempts   int       `json:"attempts"`
+	MaxRetries int       `json:"max_retries"`
+	LastError  string    `json:"last_error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	RunAt      time.Time `json:"run_at"`
+}
+
+// Handler 任务处理函数, 返回错误时按退避策略重试
+type Handler func(ctx context.Context, job *Job) error
+
+// EnqueueOption 入队选项
+type EnqueueOption func(*Job)
+
+// WithDelay 延迟执行
+func WithDelay(delay time.Duration) EnqueueOption {
+	return func(j *Job) {
+		j.RunAt = j.RunAt.Add(delay)
+	}
+}
+
+// WithPriority 设置优先级, 数值越大越先执行
+func WithPriority(priority int) EnqueueOption {
+	return func(j *Job) {
+		j.Priority = priority
+	}
+}
+
+// WithJobRetries 设置单个任务的最大重试次数
+func WithJobRetries(retries int) EnqueueOption {
+	return func(j *Job) {
+		j.MaxRetries = retries
+	}
+}
+
+// QueueOption 队列选项
+type QueueOption func(*Queue)
+
+// WithVisibility 设置可见性超时, 超时未确认的任务会被重新投递
+func WithVisibility(timeout time.Duration) QueueOption {
+	return func(q *Queue) {
+		q.visibility = timeout
+	}
+}
+
+// WithMaxRetries 设置默认最大重试次数
+func WithMaxRetries(retries int) QueueOption {
+	return func(q *Queue) {
+		q.maxRetries = retries
+	}
+}
+
+// WithBackoff 设置重试退避策略, attempt 从 1 开始
+func WithBackoff(backoff func(attempt int) time.Duration) QueueOption {
+	return func(q *Queue) {
+		q.backoff = backoff
+	}
+}
+
+// WithPollInterval 设置空闲时的轮询间隔
+func WithPollInterval(interval time.Duration) QueueOption {
+	return func(q *Queue) {
+		q.poll = interval
+	}
+}
+
+// WithQueueLogger 设置队列日志
+func WithQueueLogger(logger *slog.Logger) QueueOption {
+	return func(q *Queue) {
+		q.logger = logger
+	}
+}
+
+// ExponentialBackoff 指数退避, 从 base 开始翻倍, 不超过 max
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		if attempt < 1 {
+			attempt = 1
+		}
+		delay := float64(base) * math.Pow(2, float64(attempt-1))
+		if delay > float64(max) {
+			return max
+		}
+		return time.Duration(delay)
+	}
+}
+
+// Queue 基于 redka 的持久化任务队列
+type Queue struct {
+	db         *redka.DB
+	name       string
+	visibility time.Duration
+	maxRetries int
+	backoff    func(attempt int) time.Duration
+	poll       time.Duration
+	logger     *slog.Logger
+	codec      Codec
+	wake       chan struct{}
+}
+
+// NewQueue 创建任务队列, 同名队列共享数据
+func NewQueue(db *redka.DB, name string, opts ...QueueOption) *Queue {
+	q := &Queue{
+		db:         db,
+		name:       name,
+		visibility: 30 * time.Second,
+		maxRetries: 3,
+		backoff:    ExponentialBackoff(time.Second, time.Hour),
+		poll:       time.Second,
+		logger:     slog.Default(),
+		codec:      JSONCodec{},
+		wake:       make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// key 生成队列内部使用的键
+func (q *Queue) key(parts ...string) string {
+	key := "queue:" + q.name
+	for _, part := range parts {
+		key += ":" + part
+	}
+	return key
+}
+
+// Enqueue 入队
+func (q *Queue) Enqueue(payload []byte, opts ...EnqueueOption) (*Job, error) {
+	now := time.Now()
+	job := &Job{
+		Queue:      q.name,
+		Payload:    payload,
+		MaxRetries: q.maxRetries,
+		CreatedAt:  now,
+		RunAt:      now,
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	err := q.db.Update(func(tx *redka.Tx) error {
+		seq, err := tx.Str().Incr(q.key("seq"), 1)
+		if err != nil {
+			return err
+		}
+		// 补零保证同优先级下按入队顺序执行
+		job.ID = fmt.Sprintf("%020d", seq)
+
+		if job.RunAt.After(now) {
+			return q.schedule(tx, job, job.RunAt)
+		}
+		return q.ready(tx, job)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	q.notify()
+	return job, nil
+}
+
+// Dequeue 取出一个待执行任务, 队列为空时返回 nil
+func (q *Queue) Dequeue() (*Job, error) {
+	var job *Job
+	err := q.db.Update(func(tx *redka.Tx) error {
+		now := time.Now()
+		if err := q.maintain(tx, now); err != nil {
+			return err
+		}
+
+		items, err := tx.ZSet().RangeWith(q.key("ready")).ByRank(0, 0).Run()
+		if err != nil || len(items) == 0 {
+			return err
+		}
+
+		job, err = q.load(tx, items[0].Elem.String())
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ZSet().Delete(q.key("ready"), job.ID); err != nil {
+			return err
+		}
+
+		job.State = StateActive
+		job.Attempts++
+		deadline := now.Add(q.visibility)
+		if _, err := tx.ZSet().Add(q.key("active"), job.ID, score(deadline)); err != nil {
+			return err
+		}
+		return q.save(tx, job)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Ack 确认任务完成并删除
+func (q *Queue) Ack(job *Job) error {
+	return q.db.Update(func(tx *redka.Tx) error {
+		if err := q.checkActive(tx, job); err != nil {
+			return err
+		}
+		if _, err := tx.ZSet().Delete(q.key("active"), job.ID); err != nil {
+			return err
+		}
+		_, err := tx.Key().Delete(q.key("job", job.ID))
+		return err
+	})
+}
+
+// Nack 标记任务失败, 按退避策略重试或进入死信
+func (q *Queue) Nack(job *Job, cause error) error {
+	msg := "unknown error"
+	if cause != nil {
+		msg = cause.Error()
+	}
+	return q.db.Update(func(tx *redka.Tx) error {
+		if err := q.checkActive(tx, job); err != nil {
+			return err
+		}
+		return q.fail(tx, job, msg, time.Now())
+	})
+}
+
+// Work 启动 concurrency 个协程处理任务, 阻塞直到 ctx 取消且所有任务处理完毕
+func (q *Queue) Work(ctx context.Context, concurrency int, handler Handler) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, handler)
+		}()
+	}
+	wg.Wait()
+}
+
+// work 单个工作协程
+func (q *Queue) work(ctx context.Context, handler Handler) {
+	// 定时器创建后先停止, 空闲时再 Reset, 避免处理任务期间到期的旧信号提前唤醒
+	timer := time.NewTimer(q.poll)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	defer timer.Stop()
+
+	for ctx.Err() == nil {
+		job, err := q.Dequeue()
+		if err != nil {
+			q.logger.Error("queue: dequeue", "queue", q.name, "err", err)
+		}
+		if job != nil {
+			q.process(ctx, job, handler)
+			continue
+		}
+
+		// 队列为空, 等待新任务或下一次轮询
+		timer.Reset(q.poll)
+		select {
+		case <-ctx.Done():
+		case <-q.wake:
+		case <-timer.C:
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+}
+
+// process 执行任务并确认结果
+func (q *Queue) process(ctx context.Context, job *Job, handler Handler) {
+	// 处理时间不超过可见性超时, 超时后任务会被重新投递
+	jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), q.visibility)
+	defer cancel()
+
+	err := q.call(jobCtx, job, handler)
+	if err == nil {
+		err = q.Ack(job)
+	} else {
+		q.logger.Warn("queue: job failed", "queue", q.name, "id", job.ID, "attempt", job.Attempts, "err", err)
+		err = q.Nack(job, err)
+	}
+	if err != nil {
+		q.logger.Error("queue: settle job", "queue", q.name, "id", job.ID, "err", err)
+	}
+}
+
+// call 调用处理函数, 将 panic 转为错误
+func (q *Queue) call(ctx context.Context, job *Job, handler Handler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// Get 获取任务
+func (q *Queue) Get(id string) (*Job, error) {
+	var job *Job
+	err := q.db.View(func(tx *redka.Tx) (err error) {
+		job, err = q.load(tx, id)
+		return err
+	})
+	return job, err
+}
+
+// Len 统计指定状态的任务数量
+func (q *Queue) Len(state JobState) (int, error) {
+	if state == StateDead {
+		return q.db.List().Len(q.key("dead"))
+	}
+	return q.db.ZSet().Len(q.key(string(state)))
+}
+
+// List 列出指定状态的任务
+func (q *Queue) List(state JobState) ([]*Job, error) {
+	var jobs []*Job
+	err := q.db.View(func(tx *redka.Tx) error {
+		ids, err := q.ids(tx, state)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			job, err := q.load(tx, id)
+			if err != nil {
+				return err
+			}
+			jobs = append(jobs, job)
+		}
+		return nil
+	})
+	return jobs, err
+}
+
+// Retry 立即重新执行死信或等待中的任务, 死信任务的重试次数会被重置
+func (q *Queue) Retry(id string) error {
+	err := q.db.Update(func(tx *redka.Tx) error {
+		job, err := q.load(tx, id)
+		if err != nil {
+			return err
+		}
+
+		switch job.State {
+		case StateDead:
+			if _, err := tx.List().Delete(q.key("dead"), job.ID); err != nil {
+				return err
+			}
+			job.Attempts = 0
+		case StateScheduled:
+			if _, err := tx.ZSet().Delete(q.key("scheduled"), job.ID); err != nil {
+				return err
+			}
+		default:
+			return ErrJobState
+		}
+		job.RunAt = time.Now()
+		return q.ready(tx, job)
+	})
+	if err == nil {
+		q.notify()
+	}
+	return err
+}
+
+// Purge 删除指定状态的全部任务, 返回删除数量
+func (q *Queue) Purge(state JobState) (int, error) {
+	var count int
+	err := q.db.Update(func(tx *redka.Tx) error {
+		ids, err := q.ids(tx, state)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		keys := make([]string, len(ids))
+		for i, id := range ids {
+			keys[i] = q.key("job", id)
+		}
+		if count, err = tx.Key().Delete(keys...); err != nil {
+			return err
+		}
+		_, err = tx.Key().Delete(q.key(string(state)))
+		return err
+	})
+	return count, err
+}
+
+// ids 读取指定状态下的任务 ID
+func (q *Queue) ids(tx *redka.Tx, state JobState) ([]string, error) {
+	var ids []string
+	switch state {
+	case StateDead:
+		values, err := tx.List().Range(q.key("dead"), 0, -1)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			ids = append(ids, v.String())
+		}
+	case StateScheduled, StateReady, StateActive:
+		items, err := tx.ZSet().RangeWith(q.key(string(state))).ByRank(0, math.MaxInt32).Run()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			ids = append(ids, item.Elem.String())
+		}
+	default:
+		return nil, fmt.Errorf("unknown job state %q", state)
+	}
+	return ids, nil
+}
+
+// maintain 将到期的延迟任务转为就绪, 回收可见性超时的任务
+func (q *Queue) maintain(tx *redka.Tx, now time.Time) error {
+	due, err := tx.ZSet().RangeWith(q.key("scheduled")).
+		ByScore(-math.MaxFloat64, score(now)).Count(maintainBatch).Run()
+	if err != nil {
+		return err
+	}
+	for _, item := range due {
+		job, err := q.load(tx, item.Elem.String())
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ZSet().Delete(q.key("scheduled"), job.ID); err != nil {
+			return err
+		}
+		if err := q.ready(tx, job); err != nil {
+			return err
+		}
+	}
+
+	expired, err := tx.ZSet().RangeWith(q.key("active")).
+		ByScore(-math.MaxFloat64, score(now)).Count(maintainBatch).Run()
+	if err != nil {
+		return err
+	}
+	for _, item := range expired {
+		job, err := q.load(tx, item.Elem.String())
+		if err != nil {
+			return err
+		}
+		q.logger.Warn("queue: visibility timeout", "queue", q.name, "id", job.ID, "attempt", job.Attempts)
+		if err := q.fail(tx, job, "visibility timeout", now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fail 处理失败的执行中任务
+func (q *Queue) fail(tx *redka.Tx, job *Job, msg string, now time.Time) error {
+	if _, err := tx.ZSet().Delete(q.key("active"), job.ID); err != nil {
+		return err
+	}
+	job.LastError = msg
+
+	if job.Attempts > job.MaxRetries {
+		job.State = StateDead
+		if _, err := tx.List().PushBack(q.key("dead"), job.ID); err != nil {
+			return err
+		}
+		return q.save(tx, job)
+	}
+	return q.schedule(tx, job, now.Add(q.backoff(job.Attempts)))
+}
+
+// schedule 放入延迟集合
+func (q *Queue) schedule(tx *redka.Tx, job *Job, at time.Time) error {
+	job.State = StateScheduled
+	job.RunAt = at
+	if _, err := tx.ZSet().Add(q.key("scheduled"), job.ID, score(at)); err != nil {
+		return err
+	}
+	return q.save(tx, job)
+}
+
+// ready 放入就绪集合, 优先级高的分数低
+func (q *Queue) ready(tx *redka.Tx, job *Job) error {
+	job.State = StateReady
+	if _, err := tx.ZSet().Add(q.key("ready"), job.ID, float64(-job.Priority)); err != nil {
+		return err
+	}
+	return q.save(tx, job)
+}
+
+// checkActive 确认任务仍处于本次投递中
+func (q *Queue) checkActive(tx *redka.Tx, job *Job) error {
+	current, err := q.load(tx, job.ID)
+	if errors.Is(err, redka.ErrNotFound) {
+		return ErrJobLost
+	}
+	if err != nil {
+		return err
+	}
+	if current.State != StateActive || current.Attempts != job.Attempts {
+		return ErrJobLost
+	}
+	return nil
+}
+
+// load 读取任务
+func (q *Queue) load(tx *redka.Tx, id string) (*Job, error) {
+	data, err := tx.Str().Get(q.key("job", id))
+	if err != nil {
+		return nil, err
+	}
+	job := &Job{}
+	if err := q.codec.Unmarshal(data.Bytes(), job); err != nil {
+		return nil, fmt.Errorf("decode job %q: %w", id, err)
+	}
+	return job, nil
+}
+
+// save 保存任务
+func (q *Queue) save(tx *redka.Tx, job *Job) error {
+	data, err := q.codec.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("encode job %q: %w", job.ID, err)
+	}
+	return tx.Str().Set(q.key("job", job.ID), data)
+}
+
+// notify 唤醒一个空闲的工作协程
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// score 时间转为有序集合分数(毫秒)
+func score(t time.Time) float64 {
+	return float64(t.UnixMilli())
+}
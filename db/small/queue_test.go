@@ -0,0 +1,150 @@
+package small
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQueuePriority(t *testing.T) {
+	q := NewQueue(newTestDB(t), "jobs")
+
+	q.Enqueue([]byte("low"))
+	q.Enqueue([]byte("high"), WithPriority(10))
+	q.Enqueue([]byte("low2"))
+	q.Enqueue([]byte("later"), WithDelay(time.Hour))
+
+	var got []string
+	for {
+		job, err := q.Dequeue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job == nil {
+			break
+		}
+		got = append(got, string(job.Payload))
+	}
+
+	want := []string{"high", "low", "low2"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+
+	if n, _ := q.Len(StateScheduled); n != 1 {
+		t.Errorf("expected 1 scheduled job, got %d", n)
+	}
+}
+
+func TestQueueRetryAndDead(t *testing.T) {
+	q := NewQueue(newTestDB(t), "jobs",
+		WithMaxRetries(1),
+		WithBackoff(func(int) time.Duration { return 0 }),
+	)
+	q.Enqueue([]byte("boom"))
+
+	for i := 0; i < 2; i++ {
+		job, err := q.Dequeue()
+		if err != nil || job == nil {
+			t.Fatalf("attempt %d: job=%v err=%v", i+1, job, err)
+		}
+		if err := q.Nack(job, errors.New("boom")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dead, err := q.List(StateDead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].Attempts != 2 || dead[0].LastError != "boom" {
+		t.Fatalf("unexpected dead jobs: %+v", dead)
+	}
+
+	if err := q.Retry(dead[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	job, _ := q.Dequeue()
+	if job == nil || job.Attempts != 1 {
+		t.Fatalf("expected retried job, got %+v", job)
+	}
+	if err := q.Ack(job); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Get(job.ID); err == nil {
+		t.Errorf("expected acked job to be removed")
+	}
+}
+
+func TestQueueVisibilityTimeout(t *testing.T) {
+	q := NewQueue(newTestDB(t), "jobs",
+		WithVisibility(10*time.Millisecond),
+		WithBackoff(func(int) time.Duration { return 0 }),
+	)
+	q.Enqueue([]byte("slow"))
+
+	first, _ := q.Dequeue()
+	if first == nil {
+		t.Fatal("expected a job")
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// 第一次 Dequeue 回收超时任务, 第二次取到重新投递的任务
+	second, _ := q.Dequeue()
+	if second == nil {
+		second, _ = q.Dequeue()
+	}
+	if second == nil || second.ID != first.ID || second.Attempts != 2 {
+		t.Fatalf("expected redelivered job, got %+v", second)
+	}
+	if err := q.Ack(first); !errors.Is(err, ErrJobLost) {
+		t.Errorf("expected ErrJobLost, got %v", err)
+	}
+	if err := q.Ack(second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQueueWorkAndPurge(t *testing.T) {
+	q := NewQueue(newTestDB(t), "jobs", WithPollInterval(5*time.Millisecond), WithMaxRetries(0))
+
+	for i := 0; i < 10; i++ {
+		q.Enqueue([]byte("ok"))
+	}
+	q.Enqueue([]byte("fail"))
+
+	var done atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for {
+			if n, _ := q.Len(StateDead); n == 1 && done.Load() == 10 {
+				cancel()
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	q.Work(ctx, 4, func(ctx context.Context, job *Job) error {
+		if string(job.Payload) == "fail" {
+			panic("fail")
+		}
+		done.Add(1)
+		return nil
+	})
+
+	count, err := q.Purge(StateDead)
+	if err != nil || count != 1 {
+		t.Fatalf("purge: count=%d err=%v", count, err)
+	}
+	if n, _ := q.Len(StateDead); n != 0 {
+		t.Errorf("expected empty dead list, got %d", n)
+	}
+}
@@ -1,34 +1,88 @@
 package small
 
 import (
+	"fmt"
 	"log/slog"
+	"sync/atomic"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/nalgeon/redka"
 )
 
-var (
-	err         error
-	RED_DB      *redka.DB
-	CONNECT_DNS string
+const (
+	// DefaultPath 默认数据库文件
+	DefaultPath = "_app.db"
+	// MemoryPath 内存数据库, 每次打开都是独立的实例
+	MemoryPath = ":memory:"
 )
 
-func checkParams() (have bool) {
-	have = CONNECT_DNS == ""
-	if !have {
-		CONNECT_DNS = "_app.db"
+// memorySeq 内存数据库序号, 保证多个实例互不干扰
+var memorySeq atomic.Int64
+
+// config 嵌入式存储配置
+type config struct {
+	options *redka.Options
+	logger  *slog.Logger
+}
+
+// Option 函数选项式
+type Option func(*config)
+
+// WithOptions 设置 redka 配置
+func WithOptions(opts *redka.Options) Option {
+	return func(c *config) {
+		c.options = opts
 	}
-	return have
 }
 
-func init() {
-	checkParams()
+// WithLogger 设置日志
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// dataSource 处理路径, 空路径使用默认文件
+func dataSource(path string) string {
+	switch path {
+	case "":
+		return DefaultPath
+	case MemoryPath:
+		// redka 会把 ":memory:" 映射到同一个共享缓存库, 这里为每个实例单独命名
+		return fmt.Sprintf("file:small-%d?mode=memory&cache=shared", memorySeq.Add(1))
+	}
+	return path
 }
 
-func NewRedDB() *redka.DB {
-	RED_DB, err = redka.Open(CONNECT_DNS, nil)
+// NewRedDB 打开一个独立的嵌入式存储
+func NewRedDB(path string, opts ...Option) (*redka.DB, error) {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	options := redka.Options{}
+	if c.options != nil {
+		options = *c.options
+	}
+	if options.Logger == nil {
+		options.Logger = c.logger
+	}
+
+	db, err := redka.Open(dataSource(path), &options)
 	if err != nil {
-		slog.Error("Connect RED_DB: ", "err", err)
+		if c.logger != nil {
+			c.logger.Error("Connect RED_DB: ", "path", path, "err", err)
+		}
+		return nil, err
+	}
+	return db, nil
+}
+
+// Close 关闭存储, 允许传入 nil
+func Close(db *redka.DB) error {
+	if db == nil {
+		return nil
 	}
-	return RED_DB
+	return db.Close()
 }
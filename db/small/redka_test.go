@@ -8,7 +8,11 @@ import (
 )
 
 func TestRedDB(t *testing.T) {
-	db := NewRedDB()
+	db, err := NewRedDB(MemoryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(db)
 
 	db.Str().Set("name", "alice")
 	db.Str().Set("age", 25)
@@ -34,5 +38,31 @@ func TestRedDB(t *testing.T) {
 	})
 
 	t.Log("updated", "count", updCount, "err", err)
-	defer db.Close()
+}
+
+func TestRedDBIndependent(t *testing.T) {
+	a, err := NewRedDB(MemoryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(a)
+
+	b, err := NewRedDB(MemoryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(b)
+
+	if err := a.Str().Set("name", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Str().Get("name"); err != redka.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCloseNil(t *testing.T) {
+	if err := Close(nil); err != nil {
+		t.Errorf("Close(nil) = %v", err)
+	}
 }
@@ -0,0 +1,292 @@
+package small
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 单个请求的大小限制, 与 Redis 默认的 proto-max-bulk-len 一致
+const (
+	maxBulkLen  = 512 * 1024 * 1024
+	maxArrayLen = 1024 * 1024
+)
+
+var errProtocol = errors.New("ERR Protocol error")
+
+// respReader 读取 RESP 请求, 同时支持 inline 命令
+type respReader struct {
+	r *bufio.Reader
+}
+
+func newRespReader(r io.Reader) *respReader {
+	return &respReader{r: bufio.NewReader(r)}
+}
+
+// Buffered 返回已缓冲但未读取的字节数, 用于判断是否还有流水线请求
+func (rr *respReader) Buffered() int {
+	return rr.r.Buffered()
+}
+
+// ReadCommand 读取一条命令, 返回命令参数
+func (rr *respReader) ReadCommand() ([][]byte, error) {
+	for {
+		prefix, err := rr.r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if prefix[0] == '*' {
+			args, err := rr.readArray()
+			// *0 和 *-1 视为空命令, 与 Redis 一样忽略
+			if err != nil || len(args) > 0 {
+				return args, err
+			}
+			continue
+		}
+
+		line, err := rr.readLine()
+		if err != nil {
+			return nil, err
+		}
+		// 跳过空行
+		if args := parseInline(line); len(args) > 0 {
+			return args, nil
+		}
+	}
+}
+
+func (rr *respReader) readArray() ([][]byte, error) {
+	line, err := rr.readLine()
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n > maxArrayLen {
+		return nil, errProtocol
+	}
+
+	args := make([][]byte, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		line, err := rr.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, errProtocol
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxBulkLen {
+			return nil, errProtocol
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(rr.r, buf); err != nil {
+			return nil, err
+		}
+		if buf[size] != '\r' || buf[size+1] != '\n' {
+			return nil, errProtocol
+		}
+		args = append(args, buf[:size])
+	}
+	return args, nil
+}
+
+func (rr *respReader) readLine() (string, error) {
+	line, err := rr.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// parseInline 解析 inline 命令, 支持单引号和双引号
+func parseInline(line string) [][]byte {
+	var (
+		args  [][]byte
+		cur   []byte
+		quote byte
+		inArg bool
+	)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			if c == '\\' && quote == '"' && i+1 < len(line) {
+				i++
+				c = line[i]
+			}
+			cur = append(cur, c)
+		case c == '"' || c == '\'':
+			quote = c
+			inArg = true
+		case c == ' ' || c == '\t':
+			if inArg {
+				args = append(args, cur)
+				cur, inArg = nil, false
+			}
+		default:
+			cur = append(cur, c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, cur)
+	}
+	return args
+}
+
+// respWriter 写入 RESP2/RESP3 回复
+type respWriter struct {
+	w     *bufio.Writer
+	proto int
+}
+
+func newRespWriter(w io.Writer) *respWriter {
+	return &respWriter{w: bufio.NewWriter(w), proto: 2}
+}
+
+func (rw *respWriter) Flush() error {
+	return rw.w.Flush()
+}
+
+// Simple 简单字符串
+func (rw *respWriter) Simple(s string) {
+	rw.w.WriteByte('+')
+	rw.w.WriteString(s)
+	rw.w.WriteString("\r\n")
+}
+
+// OK 回复 +OK
+func (rw *respWriter) OK() {
+	rw.Simple("OK")
+}
+
+// Error 错误, 没有错误码前缀时补充 ERR
+func (rw *respWriter) Error(msg string) {
+	if code, _, _ := strings.Cut(msg, " "); code == "" || strings.ToUpper(code) != code {
+		msg = "ERR " + msg
+	}
+	rw.w.WriteByte('-')
+	rw.w.WriteString(strings.ReplaceAll(msg, "\r\n", " "))
+	rw.w.WriteString("\r\n")
+}
+
+// Int 整数
+func (rw *respWriter) Int(n int) {
+	rw.header(':', n)
+}
+
+// Bool 以整数 0/1 回复, 与 Redis 一致
+func (rw *respWriter) Bool(b bool) {
+	n := 0
+	if b {
+		n = 1
+	}
+	rw.Int(n)
+}
+
+// Bulk 二进制安全字符串
+func (rw *respWriter) Bulk(b []byte) {
+	rw.header('$', len(b))
+	rw.w.Write(b)
+	rw.w.WriteString("\r\n")
+}
+
+// BulkString 字符串形式的 Bulk
+func (rw *respWriter) BulkString(s string) {
+	rw.Bulk([]byte(s))
+}
+
+// Null 空值
+func (rw *respWriter) Null() {
+	if rw.proto >= 3 {
+		rw.w.WriteString("_\r\n")
+		return
+	}
+	rw.w.WriteString("$-1\r\n")
+}
+
+// NullArray 空数组, 用于 EXEC 中止等场景
+func (rw *respWriter) NullArray() {
+	if rw.proto >= 3 {
+		rw.w.WriteString("_\r\n")
+		return
+	}
+	rw.w.WriteString("*-1\r\n")
+}
+
+// Double RESP3 为浮点类型, RESP2 为字符串
+func (rw *respWriter) Double(f float64) {
+	if rw.proto >= 3 {
+		rw.w.WriteByte(',')
+		rw.w.WriteString(formatFloat(f))
+		rw.w.WriteString("\r\n")
+		return
+	}
+	rw.BulkString(formatFloat(f))
+}
+
+// Array 数组头
+func (rw *respWriter) Array(n int) {
+	rw.header('*', n)
+}
+
+// Map 字典头, RESP2 下为 2n 长度的数组
+func (rw *respWriter) Map(n int) {
+	if rw.proto >= 3 {
+		rw.header('%', n)
+		return
+	}
+	rw.header('*', n*2)
+}
+
+// Set 集合头, RESP2 下为数组
+func (rw *respWriter) Set(n int) {
+	if rw.proto >= 3 {
+		rw.header('~', n)
+		return
+	}
+	rw.header('*', n)
+}
+
+// Raw 写入已编码的回复
+func (rw *respWriter) Raw(b []byte) {
+	rw.w.Write(b)
+}
+
+func (rw *respWriter) header(prefix byte, n int) {
+	rw.w.WriteByte(prefix)
+	rw.w.WriteString(strconv.Itoa(n))
+	rw.w.WriteString("\r\n")
+}
+
+// formatFloat 与 Redis 一致的浮点数格式
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// parseFloat 解析浮点数, 支持 inf/+inf/-inf
+func parseFloat(s string) (float64, error) {
+	switch strings.ToLower(s) {
+	case "inf", "+inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, errNotFloat
+	}
+	return f, nil
+}
@@ -0,0 +1,591 @@
+package small
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nalgeon/redka"
+)
+
+// ErrServerClosed 服务已关闭
+var ErrServerClosed = errors.New("small: server closed")
+
+// ServerOption RESP 服务选项
+type ServerOption func(*Server)
+
+// WithPassword 设置访问密码, 客户端需要先 AUTH
+func WithPassword(password string) ServerOption {
+	return func(s *Server) {
+		s.password = password
+	}
+}
+
+// WithServerLogger 设置服务日志
+func WithServerLogger(logger *slog.Logger) ServerOption {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// Server 通过 RESP 协议暴露 redka 存储, 供 redis-cli 和 go-redis 等客户端使用
+type Server struct {
+	db       *redka.DB
+	password string
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[*conn]struct{}
+	closing  atomic.Bool
+	wg       sync.WaitGroup
+	nextID   atomic.Int64
+}
+
+// NewServer 创建 RESP 服务
+func NewServer(db *redka.DB, opts ...ServerOption) *Server {
+	s := &Server{
+		db:     db,
+		logger: slog.Default(),
+		conns:  make(map[*conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ListenAndServe 监听 TCP 地址并提供服务
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve 在 l 上接受连接, 直到 Shutdown 被调用
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closing.Load() {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listener = l
+	s.mu.Unlock()
+
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			if s.closing.Load() {
+				return ErrServerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		c := &conn{
+			server: s,
+			nc:     nc,
+			id:     s.nextID.Add(1),
+			reader: newRespReader(nc),
+			writer: newRespWriter(nc),
+			authed: s.password == "",
+		}
+
+		s.mu.Lock()
+		if s.closing.Load() {
+			s.mu.Unlock()
+			nc.Close()
+			return ErrServerClosed
+		}
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go c.serve()
+	}
+}
+
+// Addr 返回监听地址, 未启动时返回 nil
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Shutdown 停止接受新连接, 等待执行中的命令完成后关闭所有连接;
+// ctx 到期时强制关闭
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing.Store(true)
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	// 打断空闲连接的读取, 执行中的命令完成后连接会自行退出
+	for c := range s.conns {
+		c.nc.SetReadDeadline(time.Now())
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		s.mu.Lock()
+		for c := range s.conns {
+			c.nc.Close()
+		}
+		s.mu.Unlock()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// conn 客户端连接
+type conn struct {
+	server *Server
+	nc     net.Conn
+	id     int64
+	name   string
+	reader *respReader
+	writer *respWriter
+	authed bool
+	multi  bool
+	queue  [][][]byte
+	broken bool // MULTI 期间出现错误, EXEC 时中止
+
+	// WATCH 时记录的 key 状态, EXEC 时有变化则中止事务
+	watched map[string]keyState
+
+	// 事务中的回复先写入缓冲区
+	buf     bytes.Buffer
+	scratch *respWriter
+}
+
+func (c *conn) serve() {
+	defer func() {
+		if r := recover(); r != nil {
+			c.server.logger.Error("small: panic in connection", "conn", c.id, "panic", r)
+		}
+		// 关闭前写出已缓冲的回复
+		c.writer.Flush()
+		c.nc.Close()
+		c.server.mu.Lock()
+		delete(c.server.conns, c)
+		c.server.mu.Unlock()
+		c.server.wg.Done()
+	}()
+
+	for !c.server.closing.Load() {
+		args, err := c.reader.ReadCommand()
+		if err != nil {
+			if errors.Is(err, errProtocol) {
+				c.writer.Error(err.Error())
+				c.writer.Flush()
+			} else if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) && !c.server.closing.Load() {
+				c.server.logger.Debug("small: read command", "conn", c.id, "err", err)
+			}
+			return
+		}
+
+		quit := c.dispatch(args)
+		// 流水线请求处理完再统一写出
+		if quit || c.reader.Buffered() == 0 {
+			if err := c.writer.Flush(); err != nil {
+				return
+			}
+		}
+		if quit {
+			return
+		}
+	}
+}
+
+// dispatch 处理一条命令, 命令 panic 时回复错误并继续处理后续命令
+func (c *conn) dispatch(args [][]byte) (quit bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.server.logger.Error("small: panic in command handler", "conn", c.id, "command", string(args[0]), "panic", r)
+			c.writer.Error("ERR internal error")
+		}
+	}()
+	return c.handle(args)
+}
+
+// handle 处理一条命令, 返回是否需要关闭连接
+func (c *conn) handle(args [][]byte) bool {
+	name := strings.ToLower(string(args[0]))
+	w := c.writer
+
+	switch name {
+	case "quit":
+		w.OK()
+		return true
+	case "auth":
+		c.auth(args[1:])
+		return false
+	case "hello":
+		c.hello(args[1:])
+		return false
+	}
+
+	if !c.authed {
+		w.Error("NOAUTH Authentication required.")
+		return false
+	}
+
+	switch name {
+	case "multi":
+		if c.multi {
+			w.Error("ERR MULTI calls can not be nested")
+			return false
+		}
+		c.multi, c.queue, c.broken = true, nil, false
+		w.OK()
+		return false
+	case "exec":
+		if !c.multi {
+			w.Error("ERR EXEC without MULTI")
+			return false
+		}
+		c.exec()
+		return false
+	case "discard":
+		if !c.multi {
+			w.Error("ERR DISCARD without MULTI")
+			return false
+		}
+		c.multi, c.queue, c.broken, c.watched = false, nil, false, nil
+		w.OK()
+		return false
+	case "watch":
+		if c.multi {
+			w.Error("ERR WATCH inside MULTI is not allowed")
+			return false
+		}
+		c.watch(args[1:])
+		return false
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		cmd, ok = connCommands[name]
+	}
+	if !ok {
+		c.fail("ERR unknown command '" + string(args[0]) + "'")
+		return false
+	}
+	if !cmd.checkArity(len(args)) {
+		c.fail("ERR wrong number of arguments for '" + name + "' command")
+		return false
+	}
+
+	if c.multi {
+		c.queue = append(c.queue, args)
+		w.Simple("QUEUED")
+		return false
+	}
+
+	if cmd.run == nil {
+		c.connCommand(w, name, args[1:])
+		return false
+	}
+	c.execute([][][]byte{args}, cmd.readonly, false)
+	return false
+}
+
+// fail 回复错误, MULTI 期间会让后续 EXEC 中止
+func (c *conn) fail(msg string) {
+	if c.multi {
+		c.broken = true
+	}
+	c.writer.Error(msg)
+}
+
+// exec 在一个 redka 事务中执行排队的命令
+func (c *conn) exec() {
+	queue, broken := c.queue, c.broken
+	c.multi, c.queue, c.broken = false, nil, false
+	defer func() { c.watched = nil }()
+
+	if broken {
+		c.writer.Error("EXECABORT Transaction discarded because of previous errors.")
+		return
+	}
+	c.execute(queue, false, true)
+}
+
+// execute 在一个 redka 事务中执行命令, 事务提交成功后才写出回复;
+// multi 为 true 时以数组回复, 单条命令出错不影响其他命令
+func (c *conn) execute(queue [][][]byte, readonly, multi bool) {
+	bw := c.scratch
+	if bw == nil {
+		bw = newRespWriter(&c.buf)
+		c.scratch = bw
+	}
+	bw.proto = c.writer.proto
+
+	run := func(tx *redka.Tx) error {
+		c.buf.Reset()
+		bw.w.Reset(&c.buf)
+		if multi && c.watchChanged(tx) {
+			return errWatchAborted
+		}
+		if multi {
+			bw.Array(len(queue))
+		}
+		for _, args := range queue {
+			name := strings.ToLower(string(args[0]))
+			cmd, ok := commands[name]
+			if !ok {
+				c.connCommand(bw, name, args[1:])
+				continue
+			}
+			err := cmd.run(&cmdCtx{tx: tx, args: args[1:], w: bw})
+			if err != nil && !multi {
+				return err
+			}
+			if err != nil {
+				bw.Error(replyError(err))
+			}
+		}
+		return bw.Flush()
+	}
+
+	var err error
+	if readonly {
+		err = c.server.db.View(run)
+	} else {
+		err = c.server.db.Update(run)
+	}
+	if errors.Is(err, errWatchAborted) {
+		c.writer.NullArray()
+		return
+	}
+	if err != nil {
+		c.writer.Error(replyError(err))
+		return
+	}
+	c.writer.Raw(c.buf.Bytes())
+}
+
+// errWatchAborted WATCH 的 key 在 EXEC 前被修改
+var errWatchAborted = errors.New("small: watched key changed")
+
+// keyState key 的行 ID、版本和修改时间, key 不存在时均为 0
+type keyState struct {
+	id      int
+	version int
+	mtime   int64
+}
+
+// watch 处理 WATCH key [key ...], 记录 key 当前的状态
+func (c *conn) watch(keys [][]byte) {
+	if len(keys) == 0 {
+		c.writer.Error("ERR wrong number of arguments for 'watch' command")
+		return
+	}
+	if c.watched == nil {
+		c.watched = make(map[string]keyState, len(keys))
+	}
+	for _, key := range keys {
+		// 重复 WATCH 保留第一次的状态, 与 Redis 一致
+		if _, ok := c.watched[string(key)]; ok {
+			continue
+		}
+		k, err := c.server.db.Key().Get(string(key))
+		if err != nil && !errors.Is(err, redka.ErrNotFound) {
+			c.watched = nil
+			c.writer.Error(replyError(err))
+			return
+		}
+		c.watched[string(key)] = keyState{id: k.ID, version: k.Version, mtime: k.MTime}
+	}
+	c.writer.OK()
+}
+
+// watchChanged 在事务中检查 WATCH 的 key 是否被修改、删除、创建或已过期
+func (c *conn) watchChanged(tx *redka.Tx) bool {
+	for key, state := range c.watched {
+		k, err := tx.Key().Get(key)
+		if (err != nil && !errors.Is(err, redka.ErrNotFound)) || (keyState{id: k.ID, version: k.Version, mtime: k.MTime}) != state {
+			return true
+		}
+	}
+	return false
+}
+
+// auth 处理 AUTH [username] password
+func (c *conn) auth(args [][]byte) {
+	if len(args) < 1 || len(args) > 2 {
+		c.writer.Error("ERR wrong number of arguments for 'auth' command")
+		return
+	}
+	if c.server.password == "" {
+		c.writer.Error("ERR AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?")
+		return
+	}
+	if !c.checkPassword(args[len(args)-1]) {
+		c.writer.Error("WRONGPASS invalid username-password pair or user is disabled.")
+		return
+	}
+	c.authed = true
+	c.writer.OK()
+}
+
+func (c *conn) checkPassword(password []byte) bool {
+	return subtle.ConstantTimeCompare(password, []byte(c.server.password)) == 1
+}
+
+// hello 处理 HELLO [protover [AUTH username password] [SETNAME name]]
+func (c *conn) hello(args [][]byte) {
+	proto := c.writer.proto
+	if len(args) > 0 {
+		n, err := parseInt(args[0])
+		if err != nil {
+			c.writer.Error("ERR Protocol version is not an integer or out of range")
+			return
+		}
+		if n != 2 && n != 3 {
+			c.writer.Error("NOPROTO unsupported protocol version")
+			return
+		}
+		proto = n
+		args = args[1:]
+	}
+
+	for len(args) > 0 {
+		switch strings.ToLower(string(args[0])) {
+		case "auth":
+			if len(args) < 3 {
+				c.writer.Error("ERR syntax error")
+				return
+			}
+			if c.server.password == "" || !c.checkPassword(args[2]) {
+				c.writer.Error("WRONGPASS invalid username-password pair or user is disabled.")
+				return
+			}
+			c.authed = true
+			args = args[3:]
+		case "setname":
+			if len(args) < 2 {
+				c.writer.Error("ERR syntax error")
+				return
+			}
+			c.name = string(args[1])
+			args = args[2:]
+		default:
+			c.writer.Error("ERR syntax error")
+			return
+		}
+	}
+
+	if !c.authed {
+		c.writer.Error("NOAUTH HELLO must be called with the client already authenticated, otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time")
+		return
+	}
+
+	w := c.writer
+	w.proto = proto
+	w.Map(7)
+	w.BulkString("server")
+	w.BulkString("redka")
+	w.BulkString("version")
+	w.BulkString("7.2.0")
+	w.BulkString("proto")
+	w.Int(proto)
+	w.BulkString("id")
+	w.Int(int(c.id))
+	w.BulkString("mode")
+	w.BulkString("standalone")
+	w.BulkString("role")
+	w.BulkString("master")
+	w.BulkString("modules")
+	w.Array(0)
+}
+
+// connCommands 不访问数据的连接命令, 只用于检查参数个数, MULTI 期间与数据命令一样排队
+var connCommands = map[string]command{
+	"ping":    {arity: -1},
+	"echo":    {arity: 2},
+	"select":  {arity: 2},
+	"client":  {arity: -2},
+	"command": {arity: -1},
+	"info":    {arity: -1},
+	"unwatch": {arity: 1},
+}
+
+// connCommand 执行连接命令, 回复写入 w, 事务中写入 EXEC 的数组回复
+func (c *conn) connCommand(w *respWriter, name string, args [][]byte) {
+	switch name {
+	case "ping":
+		if len(args) == 0 {
+			w.Simple("PONG")
+		} else if len(args) == 1 {
+			w.Bulk(args[0])
+		} else {
+			w.Error("ERR wrong number of arguments for 'ping' command")
+		}
+	case "echo":
+		w.Bulk(args[0])
+	case "select":
+		if string(args[0]) != "0" {
+			w.Error("ERR DB index is out of range")
+			return
+		}
+		w.OK()
+	case "client":
+		switch strings.ToLower(string(args[0])) {
+		case "id":
+			w.Int(int(c.id))
+		case "getname":
+			if c.name == "" {
+				w.Null()
+			} else {
+				w.BulkString(c.name)
+			}
+		case "setname":
+			if len(args) != 2 {
+				w.Error("ERR wrong number of arguments for 'client|setname' command")
+				return
+			}
+			c.name = string(args[1])
+			w.OK()
+		default:
+			// SETINFO 等命令只需要成功返回
+			w.OK()
+		}
+	case "command":
+		// redis-cli 启动时会查询命令表, 返回空表即可
+		w.Array(0)
+	case "info":
+		w.BulkString("# Server\r\nredis_version:7.2.0\r\nredka_mode:embedded\r\n")
+	case "unwatch":
+		c.watched = nil
+		w.OK()
+	}
+}
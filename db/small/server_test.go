@@ -0,0 +1,279 @@
+package small
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func startServer(t *testing.T, opts ...ServerOption) (*Server, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := NewServer(newTestDB(t), opts...)
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(l) }()
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+		if err := <-done; !errors.Is(err, ErrServerClosed) {
+			t.Errorf("Serve returned %v", err)
+		}
+	})
+	return srv, l.Addr().String()
+}
+
+func TestServerCommands(t *testing.T) {
+	_, addr := startServer(t)
+	ctx := context.Background()
+
+	for _, proto := range []int{2, 3} {
+		rdb := redis.NewClient(&redis.Options{Addr: addr, Protocol: proto})
+		defer rdb.Close()
+		rdb.FlushDB(ctx)
+
+		if err := rdb.Set(ctx, "name", "alice", time.Minute).Err(); err != nil {
+			t.Fatalf("proto %d: set: %v", proto, err)
+		}
+		if v := rdb.Get(ctx, "name").Val(); v != "alice" {
+			t.Errorf("proto %d: get = %q", proto, v)
+		}
+		if ttl := rdb.TTL(ctx, "name").Val(); ttl <= 0 || ttl > time.Minute {
+			t.Errorf("proto %d: ttl = %v", proto, ttl)
+		}
+		if err := rdb.Get(ctx, "missing").Err(); err != redis.Nil {
+			t.Errorf("proto %d: expected redis.Nil, got %v", proto, err)
+		}
+		if ok := rdb.SetNX(ctx, "name", "bob", 0).Val(); ok {
+			t.Errorf("proto %d: setnx should fail on existing key", proto)
+		}
+		if n := rdb.Incr(ctx, "counter").Val(); n != 1 {
+			t.Errorf("proto %d: incr = %d", proto, n)
+		}
+
+		rdb.HSet(ctx, "user", "name", "alice", "age", "25")
+		if m := rdb.HGetAll(ctx, "user").Val(); m["age"] != "25" || len(m) != 2 {
+			t.Errorf("proto %d: hgetall = %v", proto, m)
+		}
+
+		rdb.RPush(ctx, "list", "a", "b", "c")
+		if l := rdb.LRange(ctx, "list", 0, -1).Val(); len(l) != 3 || l[2] != "c" {
+			t.Errorf("proto %d: lrange = %v", proto, l)
+		}
+
+		rdb.SAdd(ctx, "set", "x", "y")
+		if ok := rdb.SIsMember(ctx, "set", "x").Val(); !ok {
+			t.Errorf("proto %d: sismember = false", proto)
+		}
+
+		rdb.ZAdd(ctx, "zset", redis.Z{Score: 1, Member: "one"}, redis.Z{Score: 2, Member: "two"})
+		if z := rdb.ZRangeWithScores(ctx, "zset", 0, -1).Val(); len(z) != 2 || z[1].Score != 2 {
+			t.Errorf("proto %d: zrange = %v", proto, z)
+		}
+		if z := rdb.ZRangeByScore(ctx, "zset", &redis.ZRangeBy{Min: "(1", Max: "+inf"}).Val(); len(z) != 1 || z[0] != "two" {
+			t.Errorf("proto %d: zrangebyscore = %v", proto, z)
+		}
+		if s := rdb.ZScore(ctx, "zset", "two").Val(); s != 2 {
+			t.Errorf("proto %d: zscore = %v", proto, s)
+		}
+
+		if typ := rdb.Type(ctx, "zset").Val(); typ != "zset" {
+			t.Errorf("proto %d: type = %q", proto, typ)
+		}
+		if err := rdb.HGet(ctx, "list", "x").Err(); err == nil {
+			t.Errorf("proto %d: expected WRONGTYPE error", proto)
+		}
+	}
+}
+
+func TestServerMulti(t *testing.T) {
+	_, addr := startServer(t)
+	ctx := context.Background()
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	defer rdb.Close()
+
+	cmds, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, "a", "1", 0)
+		pipe.Incr(ctx, "a")
+		pipe.Get(ctx, "a")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := cmds[2].(*redis.StringCmd).Val(); v != "2" {
+		t.Errorf("expected 2, got %q", v)
+	}
+
+	// 连接命令在事务中同样排队, 回复在 EXEC 的数组中
+	cmds, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Ping(ctx)
+		pipe.Echo(ctx, "hi")
+		pipe.Get(ctx, "a")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmds[0].(*redis.StatusCmd).Val() != "PONG" || cmds[1].(*redis.StringCmd).Val() != "hi" || cmds[2].(*redis.StringCmd).Val() != "2" {
+		t.Errorf("unexpected replies %v", cmds)
+	}
+
+	// 排队阶段出错时整个事务被丢弃
+	_, err = rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, "a", "3", 0)
+		pipe.Do(ctx, "nosuchcommand")
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected EXECABORT")
+	}
+	if v := rdb.Get(ctx, "a").Val(); v != "2" {
+		t.Errorf("expected discarded transaction, got %q", v)
+	}
+}
+
+func TestServerWatch(t *testing.T) {
+	_, addr := startServer(t)
+	ctx := context.Background()
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	defer rdb.Close()
+	other := redis.NewClient(&redis.Options{Addr: addr})
+	defer other.Close()
+
+	rdb.Set(ctx, "balance", "10", 0)
+	incr := func(tx *redis.Tx, modify bool) error {
+		n, err := tx.Get(ctx, "balance").Int()
+		if err != nil {
+			return err
+		}
+		// 其他客户端在 WATCH 和 EXEC 之间修改了 key
+		if modify {
+			other.Set(ctx, "balance", "100", 0)
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, "balance", n+1, 0)
+			return nil
+		})
+		return err
+	}
+
+	err := rdb.Watch(ctx, func(tx *redis.Tx) error { return incr(tx, true) }, "balance")
+	if !errors.Is(err, redis.TxFailedErr) {
+		t.Fatalf("expected TxFailedErr, got %v", err)
+	}
+	if v := rdb.Get(ctx, "balance").Val(); v != "100" {
+		t.Errorf("lost update: balance = %q", v)
+	}
+
+	if err := rdb.Watch(ctx, func(tx *redis.Tx) error { return incr(tx, false) }, "balance"); err != nil {
+		t.Fatal(err)
+	}
+	if v := rdb.Get(ctx, "balance").Val(); v != "101" {
+		t.Errorf("balance = %q", v)
+	}
+
+	// 不存在的 key 被创建也会让事务中止
+	err = rdb.Watch(ctx, func(tx *redis.Tx) error {
+		other.Set(ctx, "created", "1", 0)
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, "created", "2", 0)
+			return nil
+		})
+		return err
+	}, "created")
+	if !errors.Is(err, redis.TxFailedErr) {
+		t.Errorf("expected TxFailedErr for created key, got %v", err)
+	}
+}
+
+func TestServerEmptyCommand(t *testing.T) {
+	_, addr := startServer(t)
+	nc, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+
+	nc.SetDeadline(time.Now().Add(time.Second))
+	if _, err := nc.Write([]byte("*0\r\n*-1\r\n*1\r\n$4\r\nPING\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 64)
+	n, err := nc.Read(buf)
+	if err != nil || string(buf[:n]) != "+PONG\r\n" {
+		t.Errorf("got %q, %v", buf[:n], err)
+	}
+}
+
+func TestServerPanic(t *testing.T) {
+	commands["panic"] = command{1, true, func(*cmdCtx) error { panic("boom") }}
+	t.Cleanup(func() { delete(commands, "panic") })
+	_, addr := startServer(t, WithServerLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	nc, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+
+	nc.SetDeadline(time.Now().Add(time.Second))
+	if _, err := nc.Write([]byte("*1\r\n$5\r\nPANIC\r\n*1\r\n$4\r\nPING\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	want := "-ERR internal error\r\n+PONG\r\n"
+	buf := make([]byte, len(want))
+	if _, err := io.ReadFull(nc, buf); err != nil || string(buf) != want {
+		t.Errorf("got %q, %v", buf, err)
+	}
+}
+
+func TestServerAuth(t *testing.T) {
+	_, addr := startServer(t, WithPassword("secret"))
+	ctx := context.Background()
+
+	bad := redis.NewClient(&redis.Options{Addr: addr, Password: "wrong"})
+	defer bad.Close()
+	if err := bad.Ping(ctx).Err(); err == nil {
+		t.Error("expected auth error")
+	}
+
+	for _, proto := range []int{2, 3} {
+		good := redis.NewClient(&redis.Options{Addr: addr, Password: "secret", Protocol: proto})
+		if err := good.Ping(ctx).Err(); err != nil {
+			t.Errorf("proto %d: ping: %v", proto, err)
+		}
+		good.Close()
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	srv, addr := startServer(t)
+	ctx := context.Background()
+	rdb := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+	defer rdb.Close()
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.Ping(ctx).Err(); err == nil {
+		t.Error("expected error after shutdown")
+	}
+}
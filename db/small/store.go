@@ -0,0 +1,300 @@
+package small
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nalgeon/redka"
+)
+
+// scanBatch 扫描时每批读取的键数量
+const scanBatch = 500
+
+// Codec 值编解码器
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec 默认的 JSON 编解码器
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// storeConfig 类型化存储配置
+type storeConfig struct {
+	prefix   string
+	codec    Codec
+	ttl      time.Duration
+	notifier *Broker
+}
+
+// StoreOption 类型化存储选项
+type StoreOption func(*storeConfig)
+
+// WithPrefix 设置键前缀(命名空间)
+func WithPrefix(prefix string) StoreOption {
+	return func(c *storeConfig) {
+		c.prefix = prefix
+	}
+}
+
+// WithCodec 设置编解码器
+func WithCodec(codec Codec) StoreOption {
+	return func(c *storeConfig) {
+		c.codec = codec
+	}
+}
+
+// WithTTL 设置默认过期时间, 0 表示永不过期
+func WithTTL(ttl time.Duration) StoreOption {
+	return func(c *storeConfig) {
+		c.ttl = ttl
+	}
+}
+
+// WithNotifier 写入、删除和修改过期时间后发送键空间通知
+func WithNotifier(broker *Broker) StoreOption {
+	return func(c *storeConfig) {
+		c.notifier = broker
+	}
+}
+
+// Store 基于 redka 字符串的类型化键值存储
+type Store[T any] struct {
+	db       *redka.DB
+	prefix   string
+	codec    Codec
+	ttl      time.Duration
+	notifier *Broker
+}
+
+// NewStore 创建类型化存储
+func NewStore[T any](db *redka.DB, opts ...StoreOption) *Store[T] {
+	c := &storeConfig{codec: JSONCodec{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return &Store[T]{
+		db:       db,
+		prefix:   c.prefix,
+		codec:    c.codec,
+		ttl:      c.ttl,
+		notifier: c.notifier,
+	}
+}
+
+// DB 返回底层数据库
+func (s *Store[T]) DB() *redka.DB {
+	return s.db
+}
+
+// Key 返回带前缀的完整键名
+func (s *Store[T]) Key(key string) string {
+	return s.prefix + key
+}
+
+// Set 写入值, 使用默认过期时间
+func (s *Store[T]) Set(key string, value T) error {
+	return s.SetExpires(key, value, s.ttl)
+}
+
+// SetExpires 写入值并指定过期时间, 0 表示永不过期
+func (s *Store[T]) SetExpires(key string, value T, ttl time.Duration) error {
+	data, err := s.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode %q: %w", key, err)
+	}
+	if ttl > 0 {
+		err = s.db.Str().SetExpires(s.Key(key), data, ttl)
+	} else {
+		err = s.db.Str().Set(s.Key(key), data)
+	}
+	if err != nil {
+		return err
+	}
+	s.notifier.notify(EventSet, s.Key(key))
+	return nil
+}
+
+// Get 读取值, 键不存在时返回 redka.ErrNotFound
+func (s *Store[T]) Get(key string) (T, error) {
+	var value T
+	data, err := s.db.Str().Get(s.Key(key))
+	if err != nil {
+		return value, err
+	}
+	return s.decode(key, data)
+}
+
+// Delete 删除键, 返回删除数量
+func (s *Store[T]) Delete(keys ...string) (int, error) {
+	var deleted []string
+	err := s.db.Update(func(tx *redka.Tx) error {
+		deleted = deleted[:0]
+		for _, key := range keys {
+			n, err := tx.Key().Delete(s.Key(key))
+			if err != nil {
+				return err
+			}
+			if n > 0 {
+				deleted = append(deleted, s.Key(key))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range deleted {
+		s.notifier.notify(EventDel, key)
+	}
+	return len(deleted), nil
+}
+
+// Expire 修改过期时间
+func (s *Store[T]) Expire(key string, ttl time.Duration) error {
+	if err := s.db.Key().Expire(s.Key(key), ttl); err != nil {
+		return err
+	}
+	s.notifier.notify(EventExpire, s.Key(key))
+	return nil
+}
+
+// GetMany 在同一个事务中批量读取, 不存在的键不会出现在结果中
+func (s *Store[T]) GetMany(keys ...string) (map[string]T, error) {
+	items := make(map[string]T, len(keys))
+	err := s.db.View(func(tx *redka.Tx) error {
+		return s.getMany(tx, keys, items)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// SetMany 在同一个事务中批量写入, 使用默认过期时间
+func (s *Store[T]) SetMany(items map[string]T) error {
+	encoded := make(map[string][]byte, len(items))
+	for key, value := range items {
+		data, err := s.codec.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("encode %q: %w", key, err)
+		}
+		encoded[s.Key(key)] = data
+	}
+
+	err := s.db.Update(func(tx *redka.Tx) error {
+		for key, data := range encoded {
+			var err error
+			if s.ttl > 0 {
+				err = tx.Str().SetExpires(key, data, s.ttl)
+			} else {
+				err = tx.Str().Set(key, data)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for key := range encoded {
+		s.notifier.notify(EventSet, key)
+	}
+	return nil
+}
+
+// Scan 按前缀扫描并解码, 前缀相对于存储的命名空间
+func (s *Store[T]) Scan(prefix string) (map[string]T, error) {
+	items := make(map[string]T)
+	err := s.db.View(func(tx *redka.Tx) error {
+		pattern := escapeGlob(s.Key(prefix)) + "*"
+		scanner := tx.Key().Scanner(pattern, redka.TypeString, scanBatch)
+
+		batch := make([]string, 0, scanBatch)
+		for scanner.Scan() {
+			key := scanner.Key()
+			if key.Type != redka.TypeString {
+				continue
+			}
+			batch = append(batch, strings.TrimPrefix(key.Key, s.prefix))
+			if len(batch) == scanBatch {
+				if err := s.getMany(tx, batch, items); err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		return s.getMany(tx, batch, items)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// getMany 在事务中读取并解码到 items
+func (s *Store[T]) getMany(tx *redka.Tx, keys []string, items map[string]T) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	full := make([]string, len(keys))
+	for i, key := range keys {
+		full[i] = s.Key(key)
+	}
+
+	values, err := tx.Str().GetMany(full...)
+	if err != nil {
+		return err
+	}
+	for key, data := range values {
+		key = strings.TrimPrefix(key, s.prefix)
+		value, err := s.decode(key, data)
+		if err != nil {
+			return err
+		}
+		items[key] = value
+	}
+	return nil
+}
+
+// decode 解码单个值
+func (s *Store[T]) decode(key string, data redka.Value) (T, error) {
+	var value T
+	if err := s.codec.Unmarshal(data.Bytes(), &value); err != nil {
+		return value, fmt.Errorf("decode %q: %w", key, err)
+	}
+	return value, nil
+}
+
+// escapeGlob 转义 glob 通配符, 让前缀按字面量匹配
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[':
+			b.WriteByte('[')
+			b.WriteRune(r)
+			b.WriteByte(']')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
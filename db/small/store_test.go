@@ -0,0 +1,109 @@
+package small
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nalgeon/redka"
+)
+
+type user struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func newTestDB(t *testing.T) *redka.DB {
+	t.Helper()
+	db, err := NewRedDB(MemoryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Close(db) })
+	return db
+}
+
+func TestStoreSetGet(t *testing.T) {
+	store := NewStore[user](newTestDB(t), WithPrefix("user:"))
+
+	if err := store.Set("1", user{Name: "alice", Age: 25}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "alice" || got.Age != 25 {
+		t.Errorf("unexpected value: %+v", got)
+	}
+
+	if _, err := store.Get("2"); err != redka.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	raw, err := store.DB().Str().Get("user:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw.String() != `{"name":"alice","age":25}` {
+		t.Errorf("unexpected raw value: %s", raw)
+	}
+}
+
+func TestStoreTTL(t *testing.T) {
+	store := NewStore[user](newTestDB(t))
+
+	if err := store.SetExpires("tmp", user{Name: "bob"}, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := store.Get("tmp"); err != redka.ErrNotFound {
+		t.Errorf("expected expired key, got %v", err)
+	}
+}
+
+func TestStoreBatch(t *testing.T) {
+	store := NewStore[user](newTestDB(t), WithPrefix("user:"))
+
+	err := store.SetMany(map[string]user{
+		"1": {Name: "alice"},
+		"2": {Name: "bob"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := store.GetMany("1", "2", "3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items["2"].Name != "bob" {
+		t.Errorf("unexpected items: %+v", items)
+	}
+}
+
+func TestStoreScan(t *testing.T) {
+	db := newTestDB(t)
+	users := NewStore[user](db, WithPrefix("user:"))
+	other := NewStore[user](db, WithPrefix("other:"))
+
+	users.Set("a*1", user{Name: "alice"})
+	users.Set("a*2", user{Name: "anna"})
+	users.Set("ab", user{Name: "abby"})
+	other.Set("a*3", user{Name: "nobody"})
+
+	items, err := users.Scan("a*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items["a*1"].Name != "alice" || items["a*2"].Name != "anna" {
+		t.Errorf("unexpected items: %+v", items)
+	}
+
+	all, err := users.Scan("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("expected 3 items, got %d", len(all))
+	}
+}
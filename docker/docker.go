@@ -16,7 +16,7 @@ import (
 	"github.com/docker/go-connections/nat"
 )
 
-var log = logs.InitLogger()
+var log = logs.Default()
 
 // Option 结构体用于配置 Docker 容器创建的选项
 type Option struct {
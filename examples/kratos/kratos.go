@@ -8,7 +8,7 @@ import (
 	kratosHTTP "github.com/go-kratos/kratos/v2/transport/http"
 )
 
-var log = logs.InitLogger()
+var log = logs.Default()
 
 func init() {
 	gin.SetMode(gin.ReleaseMode)
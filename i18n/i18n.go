@@ -0,0 +1,373 @@
+// Package i18n 多语言消息目录, 支持从 JSON/YAML/TOML 文件加载, 消息可使用模板参数和复数形式
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// Args 模板参数, Count 同时用于选择复数形式, 可以是整数或浮点数
+type Args map[string]any
+
+// CountKey 选择复数形式的参数名
+const CountKey = "Count"
+
+// pluralForms CLDR 复数形式的名称, 包含 other 的对象视为复数消息,
+// 按消息所属语言的 CLDR 规则选择, 例如英语只有 one 和 other, 俄语有 one、few、many 和 other,
+// 语言规则中没有的形式不会被使用, 缺少的形式使用 other
+var pluralForms = map[string]bool{"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true}
+
+// text 一条消息文本, 不含 {{ 时不解析模板
+type text struct {
+	raw  string
+	tmpl *template.Template
+}
+
+func (t *text) render(args Args) string {
+	if t.tmpl == nil {
+		return t.raw
+	}
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, args); err != nil {
+		return t.raw
+	}
+	return buf.String()
+}
+
+// message 消息的各个复数形式, 普通消息只有 other
+type message map[string]*text
+
+// Catalog 消息目录, 并发安全
+type Catalog struct {
+	mu       sync.RWMutex
+	fallback language.Tag
+	tags     []language.Tag
+	messages map[language.Tag]map[string]message
+
+	// supported 与 matcher 的下标对应, 默认语言在第一个
+	supported []language.Tag
+	matcher   language.Matcher
+}
+
+// New 创建消息目录, fallback 为找不到对应语言时使用的语言
+func New(fallback string) *Catalog {
+	return &Catalog{
+		fallback: language.Make(fallback),
+		messages: make(map[language.Tag]map[string]message),
+	}
+}
+
+// Default 默认消息目录, 默认语言为中文
+var Default = New("zh")
+
+// LoadFS 从 Default 加载文件, 见 Catalog.LoadFS
+func LoadFS(fsys fs.FS, patterns ...string) error {
+	return Default.LoadFS(fsys, patterns...)
+}
+
+// T 使用 Default 翻译, 见 Catalog.T
+func T(lang, key string, args ...Args) string {
+	return Default.T(lang, key, args...)
+}
+
+// LoadFS 加载匹配 patterns 的文件, 默认为 *.json、*.yaml、*.yml 和 *.toml,
+// 文件名(不含扩展名)为语言, 例如 zh.json、en-US.yaml, 通常与 embed.FS 一起使用
+//
+//	//go:embed locales
+//	var locales embed.FS
+//	i18n.LoadFS(locales, "locales/*")
+func (c *Catalog) LoadFS(fsys fs.FS, patterns ...string) error {
+	if len(patterns) == 0 {
+		patterns = []string{"*"}
+	}
+	for _, pattern := range patterns {
+		names, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			ext := path.Ext(name)
+			if !isSupported(ext) {
+				continue
+			}
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return err
+			}
+			lang := strings.TrimSuffix(path.Base(name), ext)
+			if err := c.Load(lang, ext, data); err != nil {
+				return fmt.Errorf("i18n: %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func isSupported(ext string) bool {
+	switch ext {
+	case ".json", ".yaml", ".yml", ".toml":
+		return true
+	}
+	return false
+}
+
+// Load 按格式解析 data 并加入 lang 的消息, format 为 json、yaml、yml 或 toml, 可带前导点
+func (c *Catalog) Load(lang, format string, data []byte) error {
+	var (
+		m   map[string]any
+		err error
+	)
+	switch strings.TrimPrefix(format, ".") {
+	case "json":
+		err = json.Unmarshal(data, &m)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(data, &m)
+	case "toml":
+		err = toml.Unmarshal(data, &m)
+	default:
+		return fmt.Errorf("i18n: unsupported format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+	return c.Add(lang, m)
+}
+
+// Add 加入 lang 的消息, 嵌套对象的 key 用 . 连接, 例如 user.created;
+// 包含 other 的对象视为复数消息, 例如 {"one": "{{.Count}} file", "other": "{{.Count}} files"}
+func (c *Catalog) Add(lang string, messages map[string]any) error {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return fmt.Errorf("i18n: invalid language %q: %w", lang, err)
+	}
+	flat := make(map[string]message)
+	if err := flatten(flat, "", messages); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	existing, ok := c.messages[tag]
+	if !ok {
+		existing = make(map[string]message, len(flat))
+		c.messages[tag] = existing
+		c.tags = append(c.tags, tag)
+		c.matcher = nil
+	}
+	for k, v := range flat {
+		existing[k] = v
+	}
+	return nil
+}
+
+func flatten(dst map[string]message, prefix string, src map[string]any) error {
+	for k, v := range src {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			if isPlural(v) {
+				msg := make(message, len(v))
+				for form, s := range v {
+					t, err := parse(key, fmt.Sprint(s))
+					if err != nil {
+						return err
+					}
+					msg[form] = t
+				}
+				dst[key] = msg
+				continue
+			}
+			if err := flatten(dst, key, v); err != nil {
+				return err
+			}
+		default:
+			t, err := parse(key, fmt.Sprint(v))
+			if err != nil {
+				return err
+			}
+			dst[key] = message{"other": t}
+		}
+	}
+	return nil
+}
+
+func isPlural(m map[string]any) bool {
+	if _, ok := m["other"]; !ok {
+		return false
+	}
+	for form := range m {
+		if !pluralForms[form] {
+			return false
+		}
+	}
+	return true
+}
+
+func parse(key, s string) (*text, error) {
+	t := &text{raw: s}
+	if !strings.Contains(s, "{{") {
+		return t, nil
+	}
+	tmpl, err := template.New(key).Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("i18n: %s: %w", key, err)
+	}
+	t.tmpl = tmpl
+	return t, nil
+}
+
+// Languages 已加载的语言
+func (c *Catalog) Languages() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	langs := make([]string, len(c.tags))
+	for i, tag := range c.tags {
+		langs[i] = tag.String()
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Match 从已加载的语言中选择最匹配的一个, prefs 按优先级排列,
+// 每一项可以是语言(zh-CN)或 Accept-Language 格式(en-US,en;q=0.9), 没有匹配时返回默认语言
+func (c *Catalog) Match(prefs ...string) string {
+	return c.match(prefs...).String()
+}
+
+func (c *Catalog) match(prefs ...string) language.Tag {
+	var tags []language.Tag
+	for _, pref := range prefs {
+		if t, _, err := language.ParseAcceptLanguage(pref); err == nil {
+			tags = append(tags, t...)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(tags) == 0 || len(c.tags) == 0 {
+		return c.fallback
+	}
+	if c.matcher == nil {
+		// 默认语言放在第一个, 作为匹配失败时的结果
+		c.supported = append(c.supported[:0], c.fallback)
+		for _, tag := range c.tags {
+			if tag != c.fallback {
+				c.supported = append(c.supported, tag)
+			}
+		}
+		c.matcher = language.NewMatcher(c.supported)
+	}
+	_, index, confidence := c.matcher.Match(tags...)
+	if confidence == language.No {
+		return c.fallback
+	}
+	return c.supported[index]
+}
+
+// Has 判断 lang 或默认语言中是否有 key
+func (c *Catalog) Has(lang, key string) bool {
+	_, _, ok := c.lookup(lang, key)
+	return ok
+}
+
+// Lookup 翻译 key, lang 没有该 key 时使用默认语言, 都没有时返回 false
+func (c *Catalog) Lookup(lang, key string, args ...Args) (string, bool) {
+	msg, tag, ok := c.lookup(lang, key)
+	if !ok {
+		return "", false
+	}
+
+	var data Args
+	if len(args) > 0 {
+		data = make(Args)
+		for _, a := range args {
+			for k, v := range a {
+				data[k] = v
+			}
+		}
+	}
+	t := msg["other"]
+	if n, ok := count(data); ok {
+		if form := pluralForm(tag, n); msg[form] != nil {
+			t = msg[form]
+		}
+	}
+	return t.render(data), true
+}
+
+// T 翻译 key, 找不到时原样返回 key
+func (c *Catalog) T(lang, key string, args ...Args) string {
+	if s, ok := c.Lookup(lang, key, args...); ok {
+		return s
+	}
+	return key
+}
+
+// lookup 查找消息, 同时返回消息所属的语言, 用于选择复数形式
+func (c *Catalog) lookup(lang, key string) (message, language.Tag, bool) {
+	tag := c.match(lang)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if msg, ok := c.messages[tag][key]; ok {
+		return msg, tag, true
+	}
+	msg, ok := c.messages[c.fallback][key]
+	return msg, c.fallback, ok
+}
+
+// formNames plural.Form 对应的复数形式名称
+var formNames = map[plural.Form]string{
+	plural.Zero:  "zero",
+	plural.One:   "one",
+	plural.Two:   "two",
+	plural.Few:   "few",
+	plural.Many:  "many",
+	plural.Other: "other",
+}
+
+// pluralForm 按 tag 的 CLDR 基数规则选择复数形式, number 为十进制数字, 例如 21、1.5
+func pluralForm(tag language.Tag, number string) string {
+	intPart, frac, _ := strings.Cut(strings.TrimPrefix(number, "-"), ".")
+	digits := make([]byte, 0, len(intPart)+len(frac))
+	for _, c := range intPart + frac {
+		digits = append(digits, byte(c-'0'))
+	}
+	return formNames[plural.Cardinal.MatchDigits(tag, digits, len(intPart), len(frac))]
+}
+
+// count 取出 Count 参数的十进制表示, 不是数字时返回 false
+func count(args Args) (string, bool) {
+	v := reflect.ValueOf(args[CountKey])
+	switch {
+	case v.CanInt():
+		return strconv.FormatInt(v.Int(), 10), true
+	case v.CanUint():
+		return strconv.FormatUint(v.Uint(), 10), true
+	case v.CanFloat():
+		f := v.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return "", false
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), true
+	}
+	return "", false
+}
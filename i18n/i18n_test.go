@@ -0,0 +1,109 @@
+package i18n
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func testCatalog(t *testing.T) *Catalog {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"locales/zh.json": {Data: []byte(`{
+			"hello": "你好, {{.Name}}",
+			"user": {"created": "用户已创建"},
+			"files": {"other": "{{.Count}} 个文件"}
+		}`)},
+		"locales/en.yaml": {Data: []byte(`
+hello: "Hello, {{.Name}}"
+user:
+  created: User created
+files:
+  zero: No files
+  one: One file
+  other: "{{.Count}} files"
+`)},
+		"locales/ru.json": {Data: []byte(`{
+			"files": {"one": "{{.Count}} файл", "few": "{{.Count}} файла", "many": "{{.Count}} файлов", "other": "{{.Count}} файла"}
+		}`)},
+		"locales/fr.toml": {Data: []byte(`
+hello = "Bonjour, {{.Name}}"
+[user]
+created = "Utilisateur créé"
+`)},
+		"locales/README.md": {Data: []byte("ignored")},
+	}
+	c := New("zh")
+	if err := c.LoadFS(fsys, "locales/*"); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCatalog(t *testing.T) {
+	c := testCatalog(t)
+	if langs := c.Languages(); len(langs) != 4 {
+		t.Fatalf("unexpected languages %v", langs)
+	}
+
+	cases := []struct {
+		lang, key string
+		args      Args
+		want      string
+	}{
+		{"zh", "hello", Args{"Name": "张三"}, "你好, 张三"},
+		{"en-US", "hello", Args{"Name": "Bob"}, "Hello, Bob"},
+		{"fr", "user.created", nil, "Utilisateur créé"},
+		{"en", "files", Args{CountKey: 0}, "0 files"},
+		{"en", "files", Args{CountKey: 1}, "One file"},
+		{"en", "files", Args{CountKey: int64(5)}, "5 files"},
+		{"en", "files", Args{CountKey: 1.5}, "1.5 files"},
+		{"ru", "files", Args{CountKey: 1}, "1 файл"},
+		{"ru", "files", Args{CountKey: 21}, "21 файл"},
+		{"ru", "files", Args{CountKey: 3}, "3 файла"},
+		{"ru", "files", Args{CountKey: 5}, "5 файлов"},
+		{"ru", "files", Args{CountKey: uint(11)}, "11 файлов"},
+		{"ru", "files", Args{CountKey: 1.5}, "1.5 файла"},
+		{"zh-CN", "files", Args{CountKey: 1}, "1 个文件"},
+		{"fr", "files", Args{CountKey: 3}, "3 个文件"},
+		{"de", "user.created", nil, "用户已创建"},
+		{"en", "missing.key", nil, "missing.key"},
+	}
+	for _, tc := range cases {
+		if got := c.T(tc.lang, tc.key, tc.args); got != tc.want {
+			t.Errorf("T(%q, %q) = %q, want %q", tc.lang, tc.key, got, tc.want)
+		}
+	}
+	if _, ok := c.Lookup("en", "missing.key"); ok {
+		t.Error("missing key should not be found")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	c := testCatalog(t)
+	cases := map[string][]string{
+		"zh": {},
+		"en": {"en-GB,en;q=0.9"},
+		"fr": {"fr-CA", "en"},
+	}
+	for want, prefs := range cases {
+		if got := c.Match(prefs...); got != want {
+			t.Errorf("Match(%v) = %q, want %q", prefs, got, want)
+		}
+	}
+	if got := c.Match("ja-JP"); got != "zh" {
+		t.Errorf("unmatched language should fall back to zh, got %q", got)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	c := New("en")
+	if err := c.Load("en", "ini", nil); err == nil {
+		t.Error("expected unsupported format error")
+	}
+	if err := c.Load("en", "json", []byte(`{"bad": "{{.Name"}`)); err == nil {
+		t.Error("expected template error")
+	}
+	if err := c.Add("not a language!", nil); err == nil {
+		t.Error("expected invalid language error")
+	}
+}
@@ -0,0 +1,9 @@
+package v2
+
+import (
+	"net/http"
+	"time"
+)
+
+// Hook 请求完成后的回调, 失败时 resp 为 nil, 记录日志可以使用 logs.ClientHook
+type Hook func(req *http.Request, resp *http.Response, elapsed time.Duration, err error)
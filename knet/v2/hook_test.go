@@ -0,0 +1,47 @@
+package v2
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Fromsko/gouitls/logs"
+	"github.com/sirupsen/logrus"
+)
+
+func TestHooks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "server-secret"})
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	logger := logs.InitLogger(logs.WithOutput(&buf), logs.WithFormat(logs.FormatLogfmt), logs.WithLevel(logrus.DebugLevel))
+
+	var status int
+	NewRequest(WithMethod(http.MethodGet), WithURL(srv.URL+"/api"), WithHooks(logs.ClientHook(logger))).
+		WithHeaders(map[string]string{"Authorization": "Bearer secret-token", "X-Trace": "t1"}).
+		WithCookies([]*http.Cookie{{Name: "sid", Value: "cookie-secret"}}).
+		Send(func(resp IResponse, err error) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			status = resp.StatusCode()
+		})
+
+	out := buf.String()
+	if status != http.StatusTeapot || !strings.Contains(out, "level=warning") || !strings.Contains(out, "status=418") {
+		t.Errorf("unexpected output %q", out)
+	}
+	if !strings.Contains(out, "t1") {
+		t.Errorf("headers not logged: %q", out)
+	}
+	for _, secret := range []string{"secret-token", "cookie-secret", "server-secret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("%s leaked: %q", secret, out)
+		}
+	}
+}
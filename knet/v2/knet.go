@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"time"
 
 	"github.com/tidwall/gjson"
 )
@@ -24,6 +25,7 @@ type IRequest interface {
 	WithProxy(url string) IRequest
 	WithCookies(cookies []*http.Cookie) IRequest
 	WithHeaders(headers map[string]string) IRequest
+	WithHooks(hooks ...Hook) IRequest
 	Send(callBack func(resp IResponse, err error))
 }
 
@@ -47,6 +49,7 @@ type Request struct {
 	client   *http.Client
 	cookies  []*http.Cookie
 	headers  map[string]string
+	hooks    []Hook
 }
 
 // NewRequest 支持两种方式构造请求
@@ -107,6 +110,12 @@ func (r *Request) WithHeaders(headers map[string]string) IRequest {
 	return r
 }
 
+// WithHooks 添加请求完成后的回调为链式调用
+func (r *Request) WithHooks(hooks ...Hook) IRequest {
+	r.hooks = append(r.hooks, hooks...)
+	return r
+}
+
 // WithCookies 配置 cookies 为链式调用
 func (r *Request) WithCookies(cookies []*http.Cookie) IRequest {
 	r.cookies = cookies
@@ -131,6 +140,9 @@ func (r *Request) send() (resp *http.Response, body []byte, err error) {
 		req, err = http.NewRequest(r.method, r.fetchURL, nil)
 		req.PostForm = r.form
 	}
+	if err != nil {
+		return nil, nil, err
+	}
 
 	for key, value := range r.headers {
 		req.Header.Set(key, value)
@@ -140,7 +152,19 @@ func (r *Request) send() (resp *http.Response, body []byte, err error) {
 		req.AddCookie(cookie)
 	}
 
-	resp, err = r.client.Do(req)
+	client := r.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	start := time.Now()
+	defer func() {
+		for _, hook := range r.hooks {
+			hook(req, resp, time.Since(start), err)
+		}
+	}()
+
+	resp, err = client.Do(req)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -254,3 +278,9 @@ func WithProxy(uri string) Option {
 		}
 	}
 }
+
+func WithHooks(hooks ...Hook) Option {
+	return func(r *Request) {
+		r.hooks = append(r.hooks, hooks...)
+	}
+}
@@ -0,0 +1,19 @@
+package v2
+
+import (
+	"github.com/Fromsko/gouitls/reply/api"
+)
+
+// DecodeReply 在 Send 的回调中解析 gouitls 格式的响应, 返回 data 字段,
+// 请求失败或业务码表示失败时返回错误, 业务错误为 *api.AppError
+func DecodeReply[T any](resp IResponse, err error) (T, error) {
+	var zero T
+	if err != nil {
+		return zero, err
+	}
+	r, err := api.Decode[T](resp.StatusCode(), resp.Bytes())
+	if err != nil {
+		return zero, err
+	}
+	return r.Data, nil
+}
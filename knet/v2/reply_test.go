@@ -0,0 +1,40 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Fromsko/gouitls/reply"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDecodeReply(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/user", func(c *gin.Context) { reply.OK(c, user{Name: "alice"}) })
+	r.GET("/missing", func(c *gin.Context) { reply.Fail(c, reply.CodeNotFound.New("")) })
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	NewRequest(WithMethod(http.MethodGet), WithURL(srv.URL+"/user")).Send(func(resp IResponse, err error) {
+		u, err := DecodeReply[user](resp, err)
+		if err != nil || u.Name != "alice" {
+			t.Errorf("unexpected result %+v, %v", u, err)
+		}
+	})
+	NewRequest(WithMethod(http.MethodGet), WithURL(srv.URL+"/missing")).Send(func(resp IResponse, err error) {
+		if _, err := DecodeReply[user](resp, err); !errors.Is(err, reply.CodeNotFound) {
+			t.Errorf("expected not found, got %v", err)
+		}
+	})
+	NewRequest(WithMethod(http.MethodGet), WithURL("http://127.0.0.1:1/")).Send(func(resp IResponse, err error) {
+		if _, err := DecodeReply[user](resp, err); err == nil {
+			t.Error("expected connection error")
+		}
+	})
+}
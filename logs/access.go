@@ -0,0 +1,155 @@
+package logs
+
+import (
+	"math/rand"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// HeaderRequestID 请求 ID 的请求头
+const HeaderRequestID = "X-Request-ID"
+
+// gin.Context 中的键, 其他中间件可以设置
+const (
+	ContextUserKey      = "user"
+	ContextRequestIDKey = "request_id"
+)
+
+// accessConfig 访问日志配置
+type accessConfig struct {
+	logger    *logrus.Logger
+	sample    float64
+	skipPaths []string
+	userClaim string
+}
+
+// AccessOption 访问日志选项
+type AccessOption func(*accessConfig)
+
+// WithAccessLogger 设置日志, 默认 Default()
+func WithAccessLogger(logger *logrus.Logger) AccessOption {
+	return func(c *accessConfig) {
+		c.logger = logger
+	}
+}
+
+// WithSampleRate 成功请求的采样率, 取值 0~1, 错误请求(状态码 >= 400)总是记录
+func WithSampleRate(rate float64) AccessOption {
+	return func(c *accessConfig) {
+		c.sample = rate
+	}
+}
+
+// WithSkipPaths 不记录的路径, 支持 path.Match 通配符, 例如 /static/*
+func WithSkipPaths(paths ...string) AccessOption {
+	return func(c *accessConfig) {
+		c.skipPaths = append(c.skipPaths, paths...)
+	}
+}
+
+// WithUserClaim 从 JWT 中读取用户的字段名, 默认 username
+func WithUserClaim(claim string) AccessOption {
+	return func(c *accessConfig) {
+		c.userClaim = claim
+	}
+}
+
+// AccessLog gin 访问日志中间件, 5xx 记为 error, 4xx 记为 warning, 其余为 info
+func AccessLog(opts ...AccessOption) gin.HandlerFunc {
+	cfg := &accessConfig{sample: 1, userClaim: "username"}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		reqPath := c.Request.URL.Path
+		c.Next()
+
+		if cfg.skip(reqPath) {
+			return
+		}
+		status := c.Writer.Status()
+		if status < 400 && len(c.Errors) == 0 && cfg.sample < 1 && rand.Float64() >= cfg.sample {
+			return
+		}
+
+		logger := cfg.logger
+		if logger == nil {
+			logger = Default()
+		}
+		fields := logrus.Fields{
+			"method":     c.Request.Method,
+			"path":       reqPath,
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"bytes":      max(c.Writer.Size(), 0),
+			"client_ip":  c.ClientIP(),
+		}
+		if query := c.Request.URL.RawQuery; query != "" {
+			fields["query"] = query
+		}
+		if id := requestID(c); id != "" {
+			fields[KeyRequestID] = id
+		}
+		if user := cfg.user(c); user != "" {
+			fields[ContextUserKey] = user
+		}
+		if len(c.Errors) > 0 {
+			fields[logrus.ErrorKey] = c.Errors.String()
+		}
+
+		level := logrus.InfoLevel
+		switch {
+		case status >= 500:
+			level = logrus.ErrorLevel
+		case status >= 400 || len(c.Errors) > 0:
+			level = logrus.WarnLevel
+		}
+		logger.WithContext(c.Request.Context()).WithFields(fields).
+			Logf(level, "%s %s %d", c.Request.Method, reqPath, status)
+	}
+}
+
+func (c *accessConfig) skip(reqPath string) bool {
+	for _, pattern := range c.skipPaths {
+		if ok, _ := path.Match(pattern, reqPath); ok || pattern == reqPath {
+			return true
+		}
+	}
+	return false
+}
+
+// user 优先使用其他中间件设置的用户, 否则从 Bearer Token 中读取.
+// 这里只用于日志, 不校验签名
+func (c *accessConfig) user(ctx *gin.Context) string {
+	if user := ctx.GetString(ContextUserKey); user != "" {
+		return user
+	}
+	token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+		return ""
+	}
+	user, _ := claims[c.userClaim].(string)
+	return user
+}
+
+// requestID 依次从 gin.Context、响应头和请求头读取请求 ID
+func requestID(c *gin.Context) string {
+	if id := c.GetString(ContextRequestIDKey); id != "" {
+		return id
+	}
+	if id := c.Writer.Header().Get(HeaderRequestID); id != "" {
+		return id
+	}
+	return c.GetHeader(HeaderRequestID)
+}
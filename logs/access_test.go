@@ -0,0 +1,73 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAccessLog(t *testing.T) {
+	logger, buf := newTestLogger(WithFormat(FormatJSON))
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(AccessLog(WithAccessLogger(logger), WithSkipPaths("/health", "/static/*")))
+	r.GET("/users/:id", func(c *gin.Context) { c.String(http.StatusOK, "hello") })
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/static/app.js", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"username": "alice"}).SignedString([]byte("secret"))
+	req := httptest.NewRequest(http.MethodGet, "/users/1?x=1", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(HeaderRequestID, "req-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var data map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid json %q: %v", buf, err)
+	}
+	if data["msg"] != "GET /users/1 200" || data["status"] != float64(200) || data["bytes"] != float64(5) {
+		t.Errorf("unexpected entry: %v", data)
+	}
+	if data["user"] != "alice" || data["request_id"] != "req-1" || data["query"] != "x=1" {
+		t.Errorf("unexpected fields: %v", data)
+	}
+
+	buf.Reset()
+	for _, target := range []string{"/health", "/static/app.js"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	if buf.Len() != 0 {
+		t.Errorf("skipped paths were logged: %q", buf)
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	if out := buf.String(); !strings.Contains(out, `"level":"error"`) {
+		t.Errorf("expected error level, got %q", out)
+	}
+}
+
+func TestAccessLogSample(t *testing.T) {
+	var buf bytes.Buffer
+	logger := InitLogger(WithOutput(&buf), WithFormat(FormatLogfmt))
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(AccessLog(WithAccessLogger(logger), WithSampleRate(0)))
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/missing", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if buf.Len() != 0 {
+		t.Errorf("successful request should be sampled out: %q", buf)
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if out := buf.String(); !strings.Contains(out, "level=warning") || !strings.Contains(out, "status=404") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
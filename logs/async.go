@@ -0,0 +1,230 @@
+package logs
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy 缓冲区满时的处理方式
+type OverflowPolicy int
+
+const (
+	// OverflowBlock 阻塞写入直到有空间, 不丢日志
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest 丢弃最早的一条
+	OverflowDropOldest
+	// OverflowDropNewest 丢弃当前写入的一条
+	OverflowDropNewest
+)
+
+// AsyncWriter 异步写入, 日志先进入有界环形缓冲区, 由后台协程按批次写入.
+// 进程退出前需要调用 Close, 否则缓冲区中的日志会丢失
+type AsyncWriter struct {
+	w        io.Writer
+	size     int
+	batch    int
+	interval time.Duration
+	policy   OverflowPolicy
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	ring    [][]byte
+	head    int
+	count   int
+	queued  uint64 // 已进入缓冲区的条数, 包括被丢弃的
+	written uint64 // 已写入或丢弃的条数
+	closed  bool
+	err     error
+
+	dropped atomic.Uint64
+	wake    chan struct{}
+	done    chan struct{}
+}
+
+// AsyncOption 异步写入选项
+type AsyncOption func(*AsyncWriter)
+
+// WithBufferSize 缓冲区最多保存的日志条数, 默认 1024
+func WithBufferSize(size int) AsyncOption {
+	return func(w *AsyncWriter) {
+		w.size = size
+	}
+}
+
+// WithBatchSize 累计多少条时立即写入, 默认 64
+func WithBatchSize(size int) AsyncOption {
+	return func(w *AsyncWriter) {
+		w.batch = size
+	}
+}
+
+// WithFlushInterval 定时写入的间隔, 默认 100ms, 不大于 0 时使用默认值
+func WithFlushInterval(d time.Duration) AsyncOption {
+	return func(w *AsyncWriter) {
+		w.interval = d
+	}
+}
+
+// WithOverflow 缓冲区满时的处理方式, 默认 OverflowBlock
+func WithOverflow(policy OverflowPolicy) AsyncOption {
+	return func(w *AsyncWriter) {
+		w.policy = policy
+	}
+}
+
+// NewAsyncWriter 创建异步写入并启动后台协程
+func NewAsyncWriter(w io.Writer, opts ...AsyncOption) *AsyncWriter {
+	aw := &AsyncWriter{
+		w:        w,
+		size:     1024,
+		batch:    64,
+		interval: 100 * time.Millisecond,
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(aw)
+	}
+	aw.size = max(aw.size, 1)
+	aw.batch = min(max(aw.batch, 1), aw.size)
+	if aw.interval <= 0 {
+		aw.interval = 100 * time.Millisecond
+	}
+	aw.ring = make([][]byte, aw.size)
+	aw.cond = sync.NewCond(&aw.mu)
+
+	go aw.run()
+	return aw
+}
+
+// Write 复制 p 并放入缓冲区, 关闭后等缓冲区写完再直接同步写入
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	for w.count == w.size && w.policy == OverflowBlock && !w.closed {
+		w.notify()
+		w.cond.Wait()
+	}
+	if w.closed {
+		w.mu.Unlock()
+		// 等待后台协程写完缓冲区, 避免与其并发写入底层并保持顺序
+		<-w.done
+		return w.w.Write(p)
+	}
+
+	if w.count == w.size {
+		w.dropped.Add(1)
+		w.written++
+		if w.policy == OverflowDropNewest {
+			w.queued++
+			w.mu.Unlock()
+			return len(p), nil
+		}
+		// OverflowDropOldest
+		w.ring[w.head] = nil
+		w.head = (w.head + 1) % w.size
+		w.count--
+	}
+	w.ring[(w.head+w.count)%w.size] = bytes.Clone(p)
+	w.count++
+	w.queued++
+	if w.count >= w.batch {
+		w.notify()
+	}
+	w.mu.Unlock()
+	return len(p), nil
+}
+
+// Flush 等待当前缓冲区中的日志全部写入, 返回最近一次写入错误
+func (w *AsyncWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	target := w.queued
+	w.notify()
+	for w.written < target {
+		w.cond.Wait()
+	}
+	return w.err
+}
+
+// Close 写入缓冲区中的所有日志并停止后台协程, 不会关闭底层的 io.Writer.
+// 关闭后的写入直接同步写到底层
+func (w *AsyncWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	w.notify()
+	<-w.done
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Dropped 因缓冲区满被丢弃的日志条数
+func (w *AsyncWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Unwrap 底层的 io.Writer, 用于判断是否输出到终端
+func (w *AsyncWriter) Unwrap() io.Writer {
+	return w.w
+}
+
+// notify 唤醒后台协程, 不会阻塞
+func (w *AsyncWriter) notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	var buf bytes.Buffer
+	for {
+		select {
+		case <-w.wake:
+		case <-ticker.C:
+		}
+		for {
+			// 一次取出所有日志, 合并为一次写入
+			w.mu.Lock()
+			n, closed := w.count, w.closed
+			buf.Reset()
+			for ; w.count > 0; w.count-- {
+				buf.Write(w.ring[w.head])
+				w.ring[w.head] = nil
+				w.head = (w.head + 1) % w.size
+			}
+			w.cond.Broadcast()
+			w.mu.Unlock()
+
+			if n == 0 {
+				if closed {
+					return
+				}
+				break
+			}
+			_, err := w.w.Write(buf.Bytes())
+
+			w.mu.Lock()
+			w.written += uint64(n)
+			if err != nil {
+				w.err = err
+			}
+			w.cond.Broadcast()
+			w.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gateWriter 第一次写入时阻塞, 直到 release 被关闭
+type gateWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	entered chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{entered: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() {
+		close(w.entered)
+		<-w.release
+	})
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gateWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncOverflow(t *testing.T) {
+	cases := []struct {
+		policy  OverflowPolicy
+		want    string
+		dropped uint64
+	}{
+		{OverflowDropNewest, "abc", 1},
+		{OverflowDropOldest, "acd", 1},
+		{OverflowBlock, "abcd", 0},
+	}
+	for _, tc := range cases {
+		gate := newGateWriter()
+		w := NewAsyncWriter(gate, WithBufferSize(2), WithBatchSize(1), WithFlushInterval(time.Hour), WithOverflow(tc.policy))
+		w.Write([]byte("a"))
+		<-gate.entered // 后台协程阻塞在写入 a
+
+		w.Write([]byte("b"))
+		w.Write([]byte("c"))
+		written := make(chan struct{})
+		go func() {
+			w.Write([]byte("d"))
+			close(written)
+		}()
+		if tc.policy == OverflowBlock {
+			select {
+			case <-written:
+				t.Fatal("write should block when buffer is full")
+			case <-time.After(20 * time.Millisecond):
+			}
+			close(gate.release)
+			<-written
+		} else {
+			<-written
+			close(gate.release)
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := gate.String(); got != tc.want || w.Dropped() != tc.dropped {
+			t.Errorf("policy %d: got %q dropped %d, want %q dropped %d", tc.policy, got, w.Dropped(), tc.want, tc.dropped)
+		}
+	}
+}
+
+func TestAsyncBatch(t *testing.T) {
+	var mu sync.Mutex
+	var writes []string
+	out := writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		writes = append(writes, string(p))
+		return len(p), nil
+	})
+
+	w := NewAsyncWriter(out, WithBatchSize(3), WithFlushInterval(time.Hour))
+	defer w.Close()
+	w.Write([]byte("1"))
+	w.Write([]byte("2"))
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	if len(writes) != 0 {
+		t.Errorf("written before batch is full: %q", writes)
+	}
+	mu.Unlock()
+
+	w.Write([]byte("3"))
+	w.Write([]byte("4"))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(writes, ""); got != "1234" || len(writes) > 2 {
+		t.Errorf("unexpected writes %q", writes)
+	}
+}
+
+func TestAsyncInterval(t *testing.T) {
+	gate := newGateWriter()
+	close(gate.release)
+	w := NewAsyncWriter(gate, WithFlushInterval(10*time.Millisecond))
+	defer w.Close()
+	w.Write([]byte("tick"))
+
+	deadline := time.Now().Add(time.Second)
+	for gate.String() != "tick" {
+		if time.Now().After(deadline) {
+			t.Fatal("entry not flushed by interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 间隔不大于 0 时使用默认值, 不能让后台协程 panic
+	for _, d := range []time.Duration{0, -time.Second} {
+		w := NewAsyncWriter(io.Discard, WithFlushInterval(d))
+		w.Write([]byte("x"))
+		if err := w.Close(); err != nil {
+			t.Errorf("interval %v: %v", d, err)
+		}
+	}
+}
+
+func TestAsyncLogger(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewAsyncWriter(&buf, WithBufferSize(16), WithFlushInterval(time.Hour))
+	logger := InitLogger(WithOutput(w), WithFormat(FormatLogfmt))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				logger.WithField("n", fmt.Sprintf("%d-%d", i, j)).Info("hello")
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "msg=hello"); n != 400 {
+		t.Errorf("expected 400 entries, got %d", n)
+	}
+
+	// 关闭后同步写入
+	logger.Info("late")
+	if !strings.Contains(buf.String(), "msg=late") {
+		t.Error("entry written after Close was lost")
+	}
+}
+
+func TestAsyncWriteDuringClose(t *testing.T) {
+	// 底层写入不加锁, 与后台协程并发写入时 -race 会报告
+	var buf bytes.Buffer
+	entered, release := make(chan struct{}), make(chan struct{})
+	first := true
+	out := writerFunc(func(p []byte) (int, error) {
+		if first {
+			first = false
+			close(entered)
+			<-release
+		}
+		return buf.Write(p)
+	})
+
+	w := NewAsyncWriter(out, WithBatchSize(1), WithFlushInterval(time.Hour))
+	w.Write([]byte("a"))
+	<-entered // 后台协程阻塞在写入 a
+	w.Write([]byte("b"))
+
+	closed := make(chan error, 1)
+	go func() { closed <- w.Close() }()
+	for {
+		w.mu.Lock()
+		c := w.closed
+		w.mu.Unlock()
+		if c {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	written := make(chan struct{})
+	go func() {
+		w.Write([]byte("c"))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("write after Close should wait for the buffer to drain")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	<-written
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "abc" {
+		t.Errorf("got %q, want abc", got)
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
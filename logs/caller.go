@@ -0,0 +1,99 @@
+package logs
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CallerMode 调用位置的显示方式
+type CallerMode int
+
+const (
+	// CallerShort 包名/文件名:行号, 默认方式
+	CallerShort CallerMode = iota
+	// CallerFull 完整路径:行号
+	CallerFull
+	// CallerFunc 函数名:行号
+	CallerFunc
+	// CallerNone 不显示调用位置
+	CallerNone
+)
+
+const maxCallerDepth = 32
+
+// 需要跳过的包, 即日志库自身
+var (
+	logrusPackage = "github.com/sirupsen/logrus"
+	slogPackage   = "log/slog"
+	logsPackage   = packageName(runtime.FuncForPC(reflect.ValueOf(packageName).Pointer()).Name())
+)
+
+// callerInfo 调用位置, 优先使用 logrus 的 ReportCaller 数据
+func callerInfo(entry *logrus.Entry, mode CallerMode) string {
+	if mode == CallerNone {
+		return ""
+	}
+
+	frame := callerFrame(entry)
+	if frame == nil {
+		return ""
+	}
+
+	switch mode {
+	case CallerFull:
+		return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+	case CallerFunc:
+		return fmt.Sprintf("%s:%d", path.Base(frame.Function), frame.Line)
+	}
+	return fmt.Sprintf("%s:%d", path.Join(path.Base(path.Dir(frame.File)), path.Base(frame.File)), frame.Line)
+}
+
+// callerFrame 调用位置的栈帧, 找不到时返回 nil
+func callerFrame(entry *logrus.Entry) *runtime.Frame {
+	if entry.HasCaller() && !skipFrame(entry.Caller) {
+		return entry.Caller
+	}
+	return findCaller()
+}
+
+// findCaller 遍历调用栈, 返回第一个不属于 logrus、slog 和 logs 包的栈帧
+func findCaller() *runtime.Frame {
+	pcs := make([]uintptr, maxCallerDepth)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !skipFrame(&frame) {
+			return &frame
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// skipFrame logrus、slog 和 logs 包的栈帧需要跳过, logs 包的测试文件除外
+func skipFrame(frame *runtime.Frame) bool {
+	switch pkg := packageName(frame.Function); {
+	case pkg == logrusPackage, strings.HasPrefix(pkg, logrusPackage+"/"), pkg == slogPackage:
+		return true
+	case pkg == logsPackage:
+		return !strings.HasSuffix(frame.File, "_test.go")
+	}
+	return false
+}
+
+// packageName 从完整函数名中提取包名,
+// 例如 github.com/Fromsko/gouitls/logs.(*CustomText).Format
+func packageName(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
@@ -0,0 +1,65 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// line 返回调用所在行
+func line() int {
+	_, _, l, _ := runtime.Caller(1)
+	return l
+}
+
+func callerOf(t *testing.T, out []byte) string {
+	t.Helper()
+	var data map[string]any
+	if err := json.Unmarshal(out, &data); err != nil {
+		t.Fatalf("invalid json %q: %v", out, err)
+	}
+	caller, _ := data[KeyCaller].(string)
+	return caller
+}
+
+func TestCallerPaths(t *testing.T) {
+	for name, log := range map[string]func(l *logrus.Logger) int{
+		"direct":    func(l *logrus.Logger) int { l.Info("x"); return line() },
+		"withField": func(l *logrus.Logger) int { l.WithField("k", "v").Infof("x"); return line() },
+		"entry":     func(l *logrus.Logger) int { logrus.NewEntry(l).Logf(logrus.InfoLevel, "x"); return line() },
+	} {
+		for _, reportCaller := range []bool{false, true} {
+			logger, buf := newTestLogger(WithFormat(FormatJSON))
+			logger.SetReportCaller(reportCaller)
+
+			want := fmt.Sprintf("logs/caller_test.go:%d", log(logger))
+			if got := callerOf(t, buf.Bytes()); got != want {
+				t.Errorf("%s (report=%v): caller = %q, want %q", name, reportCaller, got, want)
+			}
+		}
+	}
+}
+
+func TestCallerModes(t *testing.T) {
+	for _, tt := range []struct {
+		mode CallerMode
+		want string
+	}{
+		{CallerShort, "logs/caller_test.go:"},
+		{CallerFull, "/logs/caller_test.go:"},
+		{CallerFunc, "logs.TestCallerModes"},
+		{CallerNone, ""},
+	} {
+		logger, buf := newTestLogger(WithFormat(FormatJSON), WithCaller(tt.mode))
+		logger.WithField("k", "v").Info("x")
+
+		got := callerOf(t, buf.Bytes())
+		if !strings.Contains(got, tt.want) || (tt.want == "" && got != "") {
+			t.Errorf("mode %d: caller = %q", tt.mode, got)
+		}
+	}
+}
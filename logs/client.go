@@ -0,0 +1,67 @@
+package logs
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RedactedHeaders 日志中需要隐藏的请求头和响应头
+var RedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// redacted 隐藏后的值
+const redacted = "***"
+
+// ClientHook 记录外部请求的日志, logger 为空时使用 Default(), 可作为 knet/v2 的 Hook 使用:
+//
+//	v2.NewRequest(v2.WithHooks(logs.ClientHook(nil)))
+//
+// 失败和 5xx 记为 error, 4xx 记为 warning, 其余为 info, 头部只在 debug 级别输出
+func ClientHook(logger *logrus.Logger) func(req *http.Request, resp *http.Response, elapsed time.Duration, err error) {
+	if logger == nil {
+		logger = Default()
+	}
+	return func(req *http.Request, resp *http.Response, elapsed time.Duration, err error) {
+		if req == nil {
+			return
+		}
+		fields := logrus.Fields{
+			"method":     req.Method,
+			"url":        req.URL.Redacted(),
+			"latency_ms": float64(elapsed.Microseconds()) / 1000,
+		}
+		if logger.IsLevelEnabled(logrus.DebugLevel) {
+			fields["request_headers"] = redactHeader(req.Header)
+		}
+
+		level := logrus.InfoLevel
+		switch {
+		case err != nil:
+			level = logrus.ErrorLevel
+			fields[logrus.ErrorKey] = err
+		case resp != nil:
+			fields["status"] = resp.StatusCode
+			if logger.IsLevelEnabled(logrus.DebugLevel) {
+				fields["response_headers"] = redactHeader(resp.Header)
+			}
+			if resp.StatusCode >= 500 {
+				level = logrus.ErrorLevel
+			} else if resp.StatusCode >= 400 {
+				level = logrus.WarnLevel
+			}
+		}
+		logger.WithContext(req.Context()).WithFields(fields).Logf(level, "%s %s", req.Method, req.URL.Redacted())
+	}
+}
+
+// redactHeader 复制头部并隐藏敏感值
+func redactHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for _, key := range RedactedHeaders {
+		if _, ok := header[key]; ok {
+			header[key] = []string{redacted}
+		}
+	}
+	return header
+}
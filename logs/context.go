@@ -0,0 +1,184 @@
+package logs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// 链路标识的字段名
+const (
+	KeyRequestID = ContextRequestIDKey
+	KeyTraceID   = "trace_id"
+	KeySpanID    = "span_id"
+)
+
+// HeaderTraceparent W3C Trace Context 请求头
+const HeaderTraceparent = "traceparent"
+
+type contextKey int
+
+const (
+	entryKey contextKey = iota
+	traceKey
+)
+
+// TraceInfo 请求链路标识
+type TraceInfo struct {
+	RequestID string
+	TraceID   string
+	SpanID    string
+}
+
+// Traceparent 转换为 W3C traceparent, 用于向下游传递
+func (t TraceInfo) Traceparent() string {
+	if t.TraceID == "" || t.SpanID == "" {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", t.TraceID, t.SpanID)
+}
+
+// fields 非空的标识
+func (t TraceInfo) fields() logrus.Fields {
+	fields := make(logrus.Fields, 3)
+	for key, value := range map[string]string{KeyRequestID: t.RequestID, KeyTraceID: t.TraceID, KeySpanID: t.SpanID} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// ContextWithTrace 将链路标识保存到 context
+func ContextWithTrace(ctx context.Context, info TraceInfo) context.Context {
+	return context.WithValue(ctx, traceKey, info)
+}
+
+// TraceFromContext 读取 context 中的链路标识, ctx 可以是 *gin.Context
+func TraceFromContext(ctx context.Context) (TraceInfo, bool) {
+	ctx = requestContext(ctx)
+	if ctx == nil {
+		return TraceInfo{}, false
+	}
+	info, ok := ctx.Value(traceKey).(TraceInfo)
+	return info, ok
+}
+
+// requestContext *gin.Context 未开启 ContextWithFallback 时不会读取请求的 context,
+// 因此使用请求的 context
+func requestContext(ctx context.Context) context.Context {
+	if c, ok := ctx.(*gin.Context); ok && c != nil && c.Request != nil {
+		return c.Request.Context()
+	}
+	return ctx
+}
+
+// WithContext 将日志保存到 context, 之后的 FromContext 都会返回它
+func WithContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, entryKey, entry)
+}
+
+// FromContext 获取 context 中的日志, 没有时基于 Default() 创建,
+// 并附带 context 中的 request_id、trace_id 和 span_id, ctx 可以是 *gin.Context
+func FromContext(ctx context.Context) *logrus.Entry {
+	ctx = requestContext(ctx)
+	if ctx == nil {
+		return logrus.NewEntry(Default())
+	}
+	if entry, ok := ctx.Value(entryKey).(*logrus.Entry); ok {
+		return entry.WithContext(ctx)
+	}
+	entry := logrus.NewEntry(Default()).WithContext(ctx)
+	if info, ok := TraceFromContext(ctx); ok {
+		entry = entry.WithFields(info.fields())
+	}
+	return entry
+}
+
+// entryData 合并字段和 context 中的链路标识, 已有的同名字段不覆盖
+func entryData(entry *logrus.Entry) logrus.Fields {
+	info, ok := TraceFromContext(entry.Context)
+	if !ok {
+		return entry.Data
+	}
+	data := info.fields()
+	for key, value := range entry.Data {
+		data[key] = value
+	}
+	return data
+}
+
+// ParseTraceparent 解析 W3C traceparent, 返回 trace_id 和上游的 span_id
+func ParseTraceparent(value string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return "", "", false
+	}
+	traceID, spanID = parts[1], parts[2]
+	if !isHex(parts[0], 2) || !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(parts[3], 2) ||
+		strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+// isHex 是否为指定长度的小写十六进制
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID 只接受较短的可见字符, 避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// TraceMiddleware gin 中间件, 接收或生成 X-Request-ID 和 W3C traceparent,
+// 保存到请求的 context, 并在响应头中返回 X-Request-ID.
+// 每个请求生成新的 span_id, 上游的 trace_id 保持不变
+func TraceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		info := TraceInfo{RequestID: c.GetHeader(HeaderRequestID)}
+		if !validRequestID(info.RequestID) {
+			info.RequestID = uuid.NewString()
+		}
+		if traceID, _, ok := ParseTraceparent(c.GetHeader(HeaderTraceparent)); ok {
+			info.TraceID = traceID
+		} else {
+			info.TraceID = randomHex(16)
+		}
+		info.SpanID = randomHex(8)
+
+		c.Set(ContextRequestIDKey, info.RequestID)
+		c.Header(HeaderRequestID, info.RequestID)
+		c.Request = c.Request.WithContext(ContextWithTrace(c.Request.Context(), info))
+		c.Next()
+	}
+}
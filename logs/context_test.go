@@ -0,0 +1,124 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func TestFromContext(t *testing.T) {
+	logger, buf := newTestLogger(WithFormat(FormatJSON))
+	info := TraceInfo{RequestID: "req-1", TraceID: strings.Repeat("a", 32), SpanID: strings.Repeat("b", 16)}
+	ctx := ContextWithTrace(context.Background(), info)
+
+	// 未保存日志时使用 Default(), 保存后返回保存的日志
+	if FromContext(ctx).Logger != Default() {
+		t.Error("expected default logger")
+	}
+	ctx = WithContext(ctx, logger.WithField("user", "alice"))
+	FromContext(ctx).Info("hello")
+
+	var data map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid json %q: %v", buf, err)
+	}
+	if data["request_id"] != "req-1" || data["trace_id"] != info.TraceID || data["span_id"] != info.SpanID || data["user"] != "alice" {
+		t.Errorf("unexpected entry: %v", data)
+	}
+}
+
+func TestContextText(t *testing.T) {
+	logger, buf := newTestLogger()
+	ctx := ContextWithTrace(context.Background(), TraceInfo{RequestID: "req-2", TraceID: "t1"})
+	logger.WithContext(ctx).WithField(KeyRequestID, "override").Info("hello")
+
+	out := buf.String()
+	if !strings.Contains(out, "request_id=override") || !strings.Contains(out, "trace_id=t1") || strings.Contains(out, "span_id") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	traceID, spanID, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok || traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || spanID != "00f067aa0ba902b7" {
+		t.Errorf("unexpected result %q %q %v", traceID, spanID, ok)
+	}
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, _, ok := ParseTraceparent(value); ok {
+			t.Errorf("%q should be invalid", value)
+		}
+	}
+}
+
+func TestTraceMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(TraceMiddleware())
+	var info TraceInfo
+	r.GET("/", func(c *gin.Context) {
+		info, _ = TraceFromContext(c.Request.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderRequestID, "req-3")
+	req.Header.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if info.RequestID != "req-3" || info.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || len(info.SpanID) != 16 || info.SpanID == "00f067aa0ba902b7" {
+		t.Errorf("unexpected trace %+v", info)
+	}
+	if w.Header().Get(HeaderRequestID) != "req-3" {
+		t.Errorf("missing response header: %v", w.Header())
+	}
+	if _, _, ok := ParseTraceparent(info.Traceparent()); !ok {
+		t.Errorf("invalid traceparent %q", info.Traceparent())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderRequestID, "bad\nid")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	if info.RequestID == "bad\nid" || len(info.RequestID) != 36 || len(info.TraceID) != 32 {
+		t.Errorf("expected generated ids, got %+v", info)
+	}
+}
+
+func TestTraceMiddlewareHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger, buf := newTestLogger(WithFormat(FormatJSON))
+	r := gin.New()
+	r.Use(TraceMiddleware())
+	var entry *logrus.Entry
+	r.GET("/", func(c *gin.Context) {
+		// 处理函数直接传入 *gin.Context
+		entry = FromContext(c)
+		logger.WithContext(c).Info("hello")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderRequestID, "req-4")
+	req.Header.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if entry.Data[KeyRequestID] != "req-4" || entry.Data[KeyTraceID] != "4bf92f3577b34da6a3ce929d0e0e4736" || entry.Data[KeySpanID] == nil {
+		t.Errorf("FromContext: unexpected fields %v", entry.Data)
+	}
+	var data map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid json %q: %v", buf, err)
+	}
+	if data["request_id"] != "req-4" || data["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || data["span_id"] != entry.Data[KeySpanID] {
+		t.Errorf("WithContext: unexpected entry %v", data)
+	}
+}
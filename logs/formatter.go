@@ -0,0 +1,215 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/sirupsen/logrus"
+)
+
+// Format 日志输出格式
+type Format string
+
+const (
+	FormatText   Format = "text"
+	FormatJSON   Format = "json"
+	FormatLogfmt Format = "logfmt"
+)
+
+// 结构化输出中的保留字段
+const (
+	KeyTime   = "time"
+	KeyLevel  = "level"
+	KeyMsg    = "msg"
+	KeyCaller = "caller"
+	KeyStack  = "stack"
+)
+
+// ParseFormat 解析格式名称, 不区分大小写
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case FormatText, FormatJSON, FormatLogfmt:
+		return f, nil
+	}
+	return "", fmt.Errorf("logs: unknown format %q", name)
+}
+
+// NewFormatter 根据格式创建 logrus.Formatter
+func NewFormatter(format Format, mode CallerMode) logrus.Formatter {
+	switch format {
+	case FormatJSON:
+		return &JSONFormatter{CallerMode: mode}
+	case FormatLogfmt:
+		return &LogfmtFormatter{CallerMode: mode}
+	}
+	return &CustomText{CallerMode: mode}
+}
+
+// JSONFormatter 每行一个 JSON 对象, 包含所有字段
+type JSONFormatter struct {
+	// TimestampFormat 时间格式, 默认 RFC3339Nano
+	TimestampFormat string
+	// CallerMode 调用位置的显示方式
+	CallerMode CallerMode
+}
+
+func (f *JSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	fields := entryData(entry)
+	data := make(map[string]any, len(fields)+5)
+	for key, value := range fields {
+		if isReserved(key) {
+			key = "fields." + key
+		}
+		data[key] = fieldValue(value)
+	}
+	if stack := errorStack(entry); stack != "" {
+		data[KeyStack] = stack
+	}
+
+	data[KeyTime] = entry.Time.Format(timestampFormat(f.TimestampFormat))
+	data[KeyLevel] = entry.Level.String()
+	data[KeyMsg] = entry.Message
+	if caller := callerInfo(entry, f.CallerMode); caller != "" {
+		data[KeyCaller] = caller
+	}
+
+	buf := entry.Buffer
+	if buf == nil {
+		buf = &bytes.Buffer{}
+	}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(data); err != nil {
+		return nil, fmt.Errorf("logs: marshal entry: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// LogfmtFormatter key=value 格式, 字段按名称排序
+type LogfmtFormatter struct {
+	// TimestampFormat 时间格式, 默认 RFC3339Nano
+	TimestampFormat string
+	// CallerMode 调用位置的显示方式
+	CallerMode CallerMode
+}
+
+func (f *LogfmtFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	buf := entry.Buffer
+	if buf == nil {
+		buf = &bytes.Buffer{}
+	}
+
+	writeKV(buf, KeyTime, entry.Time.Format(timestampFormat(f.TimestampFormat)))
+	writeKV(buf, KeyLevel, entry.Level.String())
+	if caller := callerInfo(entry, f.CallerMode); caller != "" {
+		writeKV(buf, KeyCaller, caller)
+	}
+	writeKV(buf, KeyMsg, entry.Message)
+	fields := entryData(entry)
+	for _, key := range sortedKeys(fields) {
+		name := key
+		if isReserved(key) {
+			name = "fields." + key
+		}
+		writeKV(buf, name, fmt.Sprint(fieldValue(fields[key])))
+	}
+	if stack := errorStack(entry); stack != "" {
+		writeKV(buf, KeyStack, stack)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func timestampFormat(layout string) string {
+	if layout == "" {
+		return time.RFC3339Nano
+	}
+	return layout
+}
+
+func isReserved(key string) bool {
+	switch key {
+	case KeyTime, KeyLevel, KeyMsg, KeyCaller, KeyStack:
+		return true
+	}
+	return false
+}
+
+// fieldValue error 类型转换为错误信息, 其余保持原值
+func fieldValue(v any) any {
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+	return v
+}
+
+// errorStack 提取 logrus.ErrorKey 字段中错误的堆栈,
+// 支持 %+v 输出堆栈的错误类型(例如 github.com/pkg/errors)
+func errorStack(entry *logrus.Entry) string {
+	err, ok := entry.Data[logrus.ErrorKey].(error)
+	if !ok {
+		return ""
+	}
+	if stack := fmt.Sprintf("%+v", err); stack != err.Error() {
+		return stack
+	}
+	return ""
+}
+
+func sortedKeys(data logrus.Fields) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeKV(buf *bytes.Buffer, key, value string) {
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	if needsQuote(value) {
+		buf.WriteString(strconv.Quote(value))
+	} else {
+		buf.WriteString(value)
+	}
+}
+
+func needsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f || r > 0x7e {
+			return true
+		}
+	}
+	return false
+}
+
+// isTerminal 判断输出是否为终端, 设置了 NO_COLOR 时视为非终端
+func isTerminal(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	// 异步写入等包装类型判断底层输出
+	if u, ok := w.(interface{ Unwrap() io.Writer }); ok {
+		return isTerminal(u.Unwrap())
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
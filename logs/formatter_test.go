@@ -0,0 +1,93 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// stackError %+v 输出堆栈的错误
+type stackError struct{ error }
+
+func (e stackError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprintf(s, "%s\nmain.go:10", e.Error())
+		return
+	}
+	fmt.Fprint(s, e.Error())
+}
+
+func newTestLogger(opts ...Option) (*logrus.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := InitLogger(opts...)
+	logger.SetOutput(&buf)
+	return logger, &buf
+}
+
+func TestJSONFormatter(t *testing.T) {
+	logger, buf := newTestLogger(WithFormat(FormatJSON))
+	logger.WithFields(logrus.Fields{"user": "alice", "msg": "clash"}).
+		WithError(stackError{errors.New("boom")}).
+		Info("hello")
+
+	var data map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid json %q: %v", buf, err)
+	}
+	if data["msg"] != "hello" || data["level"] != "info" || data["user"] != "alice" {
+		t.Errorf("unexpected entry: %v", data)
+	}
+	if data["fields.msg"] != "clash" {
+		t.Errorf("reserved field not prefixed: %v", data)
+	}
+	if data["error"] != "boom" || !strings.Contains(data["stack"].(string), "main.go:10") {
+		t.Errorf("unexpected error fields: %v", data)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, data["time"].(string)); err != nil {
+		t.Errorf("time is not RFC3339Nano: %v", err)
+	}
+	if _, ok := data["caller"]; !ok {
+		t.Errorf("missing caller: %v", data)
+	}
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	logger, buf := newTestLogger(WithFormat(FormatLogfmt))
+	logger.WithField("path", "/a b").WithField("n", 1).Warn("done")
+
+	out := buf.String()
+	for _, want := range []string{"level=warning", "msg=done", `path="/a b"`, "n=1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in %q", want, out)
+		}
+	}
+}
+
+func TestCustomTextFields(t *testing.T) {
+	logger, buf := newTestLogger()
+	logger.WithField("user", "alice").Info("hello")
+
+	out := buf.String()
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("expected no color for non-terminal output: %q", out)
+	}
+	if !strings.Contains(out, "hello | user=alice") {
+		t.Errorf("missing fields: %q", out)
+	}
+}
+
+func TestFormatEnv(t *testing.T) {
+	t.Setenv(EnvFormat, "JSON")
+	logger, buf := newTestLogger(WithFormat(FormatLogfmt))
+	logger.Info("hello")
+
+	if !json.Valid(buf.Bytes()) {
+		t.Errorf("expected LOG_FORMAT to override option: %q", buf)
+	}
+}
@@ -0,0 +1,45 @@
+package logs
+
+import (
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WriterHook 将日志以指定格式写入 io.Writer
+type WriterHook struct {
+	w         io.Writer
+	formatter logrus.Formatter
+	levels    []logrus.Level
+}
+
+// NewWriterHook 创建写入 hook, 未指定级别时处理所有级别
+func NewWriterHook(w io.Writer, formatter logrus.Formatter, levels ...logrus.Level) *WriterHook {
+	if len(levels) == 0 {
+		levels = logrus.AllLevels
+	}
+	return &WriterHook{w: w, formatter: formatter, levels: levels}
+}
+
+func (h *WriterHook) Levels() []logrus.Level {
+	return h.levels
+}
+
+func (h *WriterHook) Fire(entry *logrus.Entry) error {
+	b, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = h.w.Write(b)
+	return err
+}
+
+// plainFormatter 文件输出使用不带颜色的格式
+func plainFormatter(f logrus.Formatter) logrus.Formatter {
+	if text, ok := f.(*CustomText); ok {
+		plain := *text
+		plain.ForceColors, plain.DisableColors = false, true
+		return &plain
+	}
+	return f
+}
@@ -0,0 +1,243 @@
+package logs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultModule 默认日志对应的模块名
+const DefaultModule = ""
+
+// KeyModule 模块日志附带的字段名
+const KeyModule = "module"
+
+// override 临时级别, 到期后恢复为 base
+type override struct {
+	base    logrus.Level
+	expires time.Time
+	timer   *time.Timer
+}
+
+var (
+	modulesMu sync.Mutex
+	modules   = make(map[string]*logrus.Logger)
+	overrides = make(map[string]*override)
+)
+
+// Module 获取模块日志, 与默认日志共享格式、输出和 hook, 级别可单独设置
+func Module(name string) *logrus.Logger {
+	if name == DefaultModule {
+		return Default()
+	}
+
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	if logger, ok := modules[name]; ok {
+		return logger
+	}
+	logger := logrus.New()
+	logger.SetLevel(Default().GetLevel())
+	syncModule(logger, name)
+	modules[name] = logger
+	return logger
+}
+
+// syncModule 从默认日志复制格式、输出和 hook
+func syncModule(logger *logrus.Logger, name string) {
+	def := Default()
+	hooks := make(logrus.LevelHooks)
+	// 模块字段需要在其他 hook 之前添加
+	hooks.Add(fieldsHook{KeyModule: name})
+	for level, hs := range def.Hooks {
+		hooks[level] = append(hooks[level], hs...)
+	}
+	logger.SetFormatter(def.Formatter)
+	logger.SetOutput(def.Out)
+	logger.SetReportCaller(def.ReportCaller)
+	logger.ReplaceHooks(hooks)
+}
+
+// syncModules 默认日志重新配置后同步到所有模块
+func syncModules() {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	for name, logger := range modules {
+		syncModule(logger, name)
+	}
+}
+
+// lookupModule 查找模块日志, 不会创建
+func lookupModule(name string) (*logrus.Logger, error) {
+	if name == DefaultModule {
+		return Default(), nil
+	}
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	if logger, ok := modules[name]; ok {
+		return logger, nil
+	}
+	return nil, fmt.Errorf("logs: unknown module %q", name)
+}
+
+// SetLevel 设置模块的日志级别, 并取消该模块的临时级别
+func SetLevel(module string, level logrus.Level) error {
+	logger, err := lookupModule(module)
+	if err != nil {
+		return err
+	}
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	cancelOverride(module)
+	logger.SetLevel(level)
+	return nil
+}
+
+// cancelOverride 取消模块的临时级别, 调用方需持有 modulesMu
+func cancelOverride(module string) {
+	if o, ok := overrides[module]; ok {
+		o.timer.Stop()
+		delete(overrides, module)
+	}
+}
+
+// SetLevelFor 临时设置模块的日志级别, d 之后自动恢复为原级别
+func SetLevelFor(module string, level logrus.Level, d time.Duration) error {
+	if d <= 0 {
+		return errors.New("logs: override duration must be positive")
+	}
+	logger, err := lookupModule(module)
+	if err != nil {
+		return err
+	}
+
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	// 连续覆盖时恢复为第一次覆盖前的级别
+	base := logger.GetLevel()
+	if o, ok := overrides[module]; ok {
+		o.timer.Stop()
+		base = o.base
+	}
+
+	o := &override{base: base, expires: time.Now().Add(d)}
+	o.timer = time.AfterFunc(d, func() {
+		modulesMu.Lock()
+		defer modulesMu.Unlock()
+		if overrides[module] == o {
+			delete(overrides, module)
+			logger.SetLevel(o.base)
+		}
+	})
+	overrides[module] = o
+	logger.SetLevel(level)
+	return nil
+}
+
+// LevelInfo 模块级别信息
+type LevelInfo struct {
+	Module  string     `json:"module"`
+	Level   string     `json:"level"`
+	Base    string     `json:"base,omitempty"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// Levels 所有模块的当前级别, 默认日志排在第一位
+func Levels() []LevelInfo {
+	def := Default()
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	infos := []LevelInfo{levelInfo(DefaultModule, def)}
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		infos = append(infos, levelInfo(name, modules[name]))
+	}
+	return infos
+}
+
+func levelInfo(name string, logger *logrus.Logger) LevelInfo {
+	info := LevelInfo{Module: name, Level: logger.GetLevel().String()}
+	if o, ok := overrides[name]; ok {
+		expires := o.expires
+		info.Base, info.Expires = o.base.String(), &expires
+	}
+	return info
+}
+
+// levelRequest 修改级别的请求, Duration 为空表示永久生效
+type levelRequest struct {
+	Module   string `json:"module"`
+	Level    string `json:"level"`
+	Duration string `json:"duration"`
+}
+
+// LevelHandler 查看和修改日志级别的 HTTP 接口.
+// GET 返回所有模块的级别; PUT/POST 修改级别, 参数可以是 JSON 或查询参数:
+// {"module": "db", "level": "debug", "duration": "10m"}
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			if err := changeLevel(r); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		writeJSON(w, http.StatusOK, Levels())
+	})
+}
+
+// GinLevelHandler gin 版本的 LevelHandler
+func GinLevelHandler() gin.HandlerFunc {
+	return gin.WrapH(LevelHandler())
+}
+
+func changeLevel(r *http.Request) error {
+	query := r.URL.Query()
+	req := levelRequest{
+		Module:   query.Get("module"),
+		Level:    query.Get("level"),
+		Duration: query.Get("duration"),
+	}
+	if r.ContentLength != 0 && req.Level == "" {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return fmt.Errorf("invalid body: %w", err)
+		}
+	}
+
+	level, err := logrus.ParseLevel(req.Level)
+	if err != nil {
+		return err
+	}
+	if req.Duration == "" {
+		return SetLevel(req.Module, level)
+	}
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+	return SetLevelFor(req.Module, level, d)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
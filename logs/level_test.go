@@ -0,0 +1,122 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func TestModuleLogger(t *testing.T) {
+	var buf bytes.Buffer
+	Configure(WithOutput(&buf), WithFormat(FormatLogfmt))
+	defer Configure()
+
+	db := Module("test-db")
+	if Module("test-db") != db {
+		t.Fatal("Module must return the same logger")
+	}
+	if err := SetLevel("test-db", logrus.WarnLevel); err != nil {
+		t.Fatal(err)
+	}
+
+	db.Info("dropped")
+	db.Warn("kept")
+	Default().Info("default")
+	out := buf.String()
+	if strings.Contains(out, "dropped") || !strings.Contains(out, "module=test-db") || !strings.Contains(out, "default") {
+		t.Errorf("unexpected output %q", out)
+	}
+	if err := SetLevel("missing", logrus.InfoLevel); err == nil {
+		t.Error("expected error for unknown module")
+	}
+}
+
+func TestSetLevelFor(t *testing.T) {
+	logger := Module("test-timed")
+	SetLevel("test-timed", logrus.InfoLevel)
+
+	SetLevelFor("test-timed", logrus.DebugLevel, time.Hour)
+	if err := SetLevelFor("test-timed", logrus.TraceLevel, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if logger.GetLevel() != logrus.TraceLevel {
+		t.Fatalf("level = %v", logger.GetLevel())
+	}
+
+	// 连续覆盖后恢复为第一次覆盖前的级别
+	deadline := time.Now().Add(time.Second)
+	for logger.GetLevel() != logrus.InfoLevel {
+		if time.Now().After(deadline) {
+			t.Fatalf("level not reverted, got %v", logger.GetLevel())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestConfigureCancelsOverride(t *testing.T) {
+	defer Configure()
+	Configure(WithLevel(logrus.InfoLevel))
+	if err := SetLevelFor(DefaultModule, logrus.DebugLevel, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新配置后的级别不会在临时级别到期时被恢复
+	Configure(WithLevel(logrus.WarnLevel))
+	time.Sleep(50 * time.Millisecond)
+	if level := Default().GetLevel(); level != logrus.WarnLevel {
+		t.Errorf("level = %v, want warning", level)
+	}
+	if info := Levels()[0]; info.Base != "" || info.Expires != nil {
+		t.Errorf("override not cancelled: %+v", info)
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	Module("test-http")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Any("/log/level", GinLevelHandler())
+
+	do := func(method, target, body string) (*httptest.ResponseRecorder, []LevelInfo) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+		var infos []LevelInfo
+		json.Unmarshal(w.Body.Bytes(), &infos)
+		return w, infos
+	}
+	find := func(infos []LevelInfo, module string) LevelInfo {
+		for _, info := range infos {
+			if info.Module == module {
+				return info
+			}
+		}
+		return LevelInfo{}
+	}
+
+	w, infos := do(http.MethodPut, "/log/level", `{"module":"test-http","level":"error","duration":"1m"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if info := find(infos, "test-http"); info.Level != "error" || info.Expires == nil {
+		t.Errorf("unexpected info %+v", info)
+	}
+
+	_, infos = do(http.MethodPost, "/log/level?module=test-http&level=info", "")
+	if info := find(infos, "test-http"); info.Level != "info" || info.Expires != nil {
+		t.Errorf("expected override to be cancelled, got %+v", info)
+	}
+
+	if w, _ := do(http.MethodPut, "/log/level", `{"level":"loud"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+	if w, _ := do(http.MethodDelete, "/log/level", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
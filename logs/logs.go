@@ -2,83 +2,188 @@ package logs
 
 import (
 	"fmt"
-	"path"
-	"runtime"
+	"io"
 	"strings"
+	"sync"
 
 	"github.com/fatih/color"
 	"github.com/sirupsen/logrus"
 )
 
-type ErrorLog struct{ msg string }
+// ErrorLog 分级日志, 与 InitLogger 使用相同的格式、级别、环境变量和 hook.
+// 零值可用, 默认输出到 color.Output
+type ErrorLog struct {
+	msg    string
+	name   string
+	opts   []Option
+	once   sync.Once
+	logger *logrus.Logger
+}
+
+// NewErrorLog 创建分级日志, name 非空时作为 module 字段输出, 选项与 InitLogger 相同
+func NewErrorLog(name string, opts ...Option) *ErrorLog {
+	return &ErrorLog{name: name, opts: opts}
+}
+
+func NewDefaultLog() *ErrorLog {
+	return &ErrorLog{}
+}
 
-// Info
-func (err *ErrorLog) InfoMsg(msgs ...string) {
-	color.Green(
-		"[INFO] %s",
-		choiceValue(err.msg, msgs),
-	)
+// Logger 底层的 logrus.Logger, 可用于修改级别或添加 hook
+func (l *ErrorLog) Logger() *logrus.Logger {
+	l.once.Do(func() {
+		opts := append([]Option{WithOutput(colorOutput{})}, l.opts...)
+		if l.name != "" {
+			opts = append(opts, WithFields(logrus.Fields{KeyModule: l.name}))
+		}
+		l.logger = InitLogger(opts...)
+	})
+	return l.logger
+}
+
+// SetLevel 设置最低级别
+func (l *ErrorLog) SetLevel(level logrus.Level) {
+	l.Logger().SetLevel(level)
+}
+
+// Enabled 指定级别是否会输出
+func (l *ErrorLog) Enabled(level logrus.Level) bool {
+	return l.Logger().IsLevelEnabled(level)
+}
+
+// InfoMsg 多个消息以 " - " 连接
+func (l *ErrorLog) InfoMsg(msgs ...string) {
+	l.Logger().Info(choiceValue(l.msg, msgs))
 }
 
 // WarnMsg
-func (err *ErrorLog) WarnMsg(msgs ...string) {
-	color.Yellow(
-		"[WARN] %s",
-		choiceValue(err.msg, msgs),
-	)
+func (l *ErrorLog) WarnMsg(msgs ...string) {
+	l.Logger().Warn(choiceValue(l.msg, msgs))
 }
 
 // DebugMsg
-func (err *ErrorLog) DebugMsg(msgs ...string) {
-	color.Blue(
-		"[DEBUG] %s",
-		choiceValue(err.msg, msgs),
-	)
+func (l *ErrorLog) DebugMsg(msgs ...string) {
+	l.Logger().Debug(choiceValue(l.msg, msgs))
 }
 
 // ErrorMsg
-func (err *ErrorLog) ErrorMsg(msgs ...string) {
-	color.Red(
-		"[Error] %s",
-		choiceValue(err.msg, msgs),
-	)
-}
-
-// choiceValue
-func choiceValue(a, b any) (tmp string) {
-	if a.(string) == "" {
-		msgs := b.([]string)
-		switch msgLen := len(msgs); msgLen {
-		case 0:
-			panic("Must have a value to msg.")
-		case 1:
-			tmp = msgs[0]
-		default:
-			tmp = strings.Join(msgs, " - ")
+func (l *ErrorLog) ErrorMsg(msgs ...string) {
+	l.Logger().Error(choiceValue(l.msg, msgs))
+}
+
+// Debugf 格式化输出
+func (l *ErrorLog) Debugf(format string, args ...any) {
+	l.Logger().Debugf(format, args...)
+}
+
+// Infof 格式化输出
+func (l *ErrorLog) Infof(format string, args ...any) {
+	l.Logger().Infof(format, args...)
+}
+
+// Warnf 格式化输出
+func (l *ErrorLog) Warnf(format string, args ...any) {
+	l.Logger().Warnf(format, args...)
+}
+
+// Errorf 格式化输出
+func (l *ErrorLog) Errorf(format string, args ...any) {
+	l.Logger().Errorf(format, args...)
+}
+
+// Debugw 附带 key/value 字段, 例如 Debugw("visit", "url", u)
+func (l *ErrorLog) Debugw(msg string, kv ...any) {
+	l.Logger().WithFields(kvFields(kv)).Debug(msg)
+}
+
+// Infow 附带 key/value 字段
+func (l *ErrorLog) Infow(msg string, kv ...any) {
+	l.Logger().WithFields(kvFields(kv)).Info(msg)
+}
+
+// Warnw 附带 key/value 字段
+func (l *ErrorLog) Warnw(msg string, kv ...any) {
+	l.Logger().WithFields(kvFields(kv)).Warn(msg)
+}
+
+// Errorw 附带 key/value 字段
+func (l *ErrorLog) Errorw(msg string, kv ...any) {
+	l.Logger().WithFields(kvFields(kv)).Error(msg)
+}
+
+// BadKey 缺少 key 的值使用的字段名, 与 slog 一致
+const BadKey = "!BADKEY"
+
+// kvFields 将交替的 key/value 转换为字段, 多余的值使用 BadKey
+func kvFields(kv []any) logrus.Fields {
+	fields := make(logrus.Fields, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			fields[BadKey] = kv[i]
+			break
 		}
-		return tmp
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		fields[key] = kv[i+1]
 	}
-	return a.(string)
+	return fields
 }
 
-func NewDefaultLog() *ErrorLog {
-	return &ErrorLog{}
+// choiceValue 优先使用固定消息, 否则以 " - " 连接 msgs, 没有消息时返回空字符串
+func choiceValue(a, b any) string {
+	if s, _ := a.(string); s != "" {
+		return s
+	}
+	msgs, _ := b.([]string)
+	return strings.Join(msgs, " - ")
+}
+
+// colorOutput 写入 color.Output, 修改 color.Output 后立即生效
+type colorOutput struct{}
+
+func (colorOutput) Write(p []byte) (int, error) {
+	return color.Output.Write(p)
+}
+
+func (colorOutput) Unwrap() io.Writer {
+	return color.Output
 }
 
 // CustomText 自定义的日志格式
-type CustomText struct{}
+type CustomText struct {
+	// ForceColors 强制输出颜色
+	ForceColors bool
+	// DisableColors 禁用颜色, 未设置时仅在终端中输出颜色
+	DisableColors bool
+	// CallerMode 调用位置的显示方式
+	CallerMode CallerMode
+}
 
 func (f *CustomText) Format(entry *logrus.Entry) ([]byte, error) {
 	timestamp := entry.Time.Format("2006-01-02 15:04:05")
 	level := entry.Level.String()
-	callerInfo := entry.Message
-
-	// 获取当前文件名和行号(获取到当前栈堆信息)
-	_, file, line, ok := runtime.Caller(6)
-	if ok {
-		// 提取文件名并添加到日志消息
-		fileName := path.Join(path.Base(path.Dir(file)), path.Base(file))
-		callerInfo = fmt.Sprintf("%s:%d | %s", fileName, line, callerInfo)
+	msg := entry.Message
+
+	if caller := callerInfo(entry, f.CallerMode); caller != "" {
+		msg = fmt.Sprintf("%s | %s", caller, msg)
+	}
+
+	// 追加字段
+	if data := entryData(entry); len(data) > 0 {
+		fields := make([]string, 0, len(data))
+		for _, key := range sortedKeys(data) {
+			fields = append(fields, fmt.Sprintf("%s=%v", key, fieldValue(data[key])))
+		}
+		msg = fmt.Sprintf("%s | %s", msg, strings.Join(fields, " "))
+	}
+	if stack := errorStack(entry); stack != "" {
+		msg = fmt.Sprintf("%s\n%s", msg, stack)
+	}
+
+	if !f.colored(entry) {
+		return []byte(fmt.Sprintf("%s | %s | %s\n", timestamp, level, msg)), nil
 	}
 
 	// 手动设置颜色
@@ -96,17 +201,48 @@ func (f *CustomText) Format(entry *logrus.Entry) ([]byte, error) {
 		levelColor = 0 // Default color
 	}
 
-	return []byte(fmt.Sprintf("\x1b[1;%dm%s | %s | %s\x1b[0m\n", levelColor, timestamp, level, callerInfo)), nil
+	return []byte(fmt.Sprintf("\x1b[1;%dm%s | %s | %s\x1b[0m\n", levelColor, timestamp, level, msg)), nil
+}
+
+// colored 是否输出颜色
+func (f *CustomText) colored(entry *logrus.Entry) bool {
+	switch {
+	case f.ForceColors:
+		return true
+	case f.DisableColors:
+		return false
+	}
+	return entry.Logger != nil && isTerminal(entry.Logger.Out)
 }
 
-func InitLogger() *logrus.Logger {
+// InitLogger 创建日志, 默认 DebugLevel 和 CustomText 格式,
+// 环境变量 LOG_LEVEL 和 LOG_FORMAT 优先于选项
+func InitLogger(opts ...Option) *logrus.Logger {
 	logger := logrus.New()
+	newConfig(opts...).apply(logger)
+	return logger
+}
 
-	// 设置日志输出格式为自定义格式
-	logger.SetFormatter(&CustomText{})
+var (
+	defaultOnce   sync.Once
+	defaultLogger *logrus.Logger
+)
 
-	// 设置日志级别
-	logger.SetLevel(logrus.DebugLevel)
+// Default 进程级默认日志, gouitls 的其他包都使用它
+func Default() *logrus.Logger {
+	defaultOnce.Do(func() {
+		defaultLogger = InitLogger()
+	})
+	return defaultLogger
+}
 
-	return logger
+// Configure 重新配置默认日志, 已经持有 Default() 的包和模块日志同样生效.
+// 默认日志的临时级别会被取消, 到期后不会恢复为旧级别
+func Configure(opts ...Option) {
+	cfg := newConfig(opts...)
+	modulesMu.Lock()
+	cancelOverride(DefaultModule)
+	cfg.apply(Default())
+	modulesMu.Unlock()
+	syncModules()
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/fatih/color"
+	"github.com/sirupsen/logrus"
 )
 
 func TestSpiderErrorMethods(t *testing.T) {
@@ -58,3 +59,49 @@ func TestChoiceValue(t *testing.T) {
 		t.Errorf("Expected 'existing', got '%s'", nonEmptyResult)
 	}
 }
+
+func TestErrorLogLevels(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewErrorLog("spider", WithOutput(&buf), WithLevel(logrus.InfoLevel), WithFormat(FormatLogfmt))
+
+	log.DebugMsg("dropped")
+	log.Debugf("dropped %d", 1)
+	log.Infof("visited %d pages", 3)
+	log.Warnw("slow", "url", "http://a", "ms", 120, "odd")
+	log.ErrorMsg()
+	if log.Enabled(logrus.DebugLevel) {
+		t.Error("debug should be disabled")
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "dropped") {
+		t.Errorf("debug entries written: %q", out)
+	}
+	for _, want := range []string{
+		`msg="visited 3 pages"`, "module=spider", "level=warning", "url=http://a", "ms=120", "!BADKEY=odd", "level=error", "time=",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in %q", want, out)
+		}
+	}
+
+	buf.Reset()
+	log.SetLevel(logrus.DebugLevel)
+	log.Debugw("kept", "n", 1)
+	if !strings.Contains(buf.String(), "msg=kept") {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+func TestErrorLogZeroValue(t *testing.T) {
+	var output bytes.Buffer
+	color.Output = &output
+	var log ErrorLog
+	log.Infow("hello", "user", "alice")
+	if out := output.String(); !strings.Contains(out, "| info |") || !strings.Contains(out, "hello | user=alice") {
+		t.Errorf("unexpected output %q", out)
+	}
+	if choiceValue("", nil) != "" {
+		t.Error("choiceValue without messages should be empty")
+	}
+}
@@ -0,0 +1,179 @@
+package logs
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 环境变量, 优先级高于代码中的选项
+const (
+	EnvLevel  = "LOG_LEVEL"
+	EnvFormat = "LOG_FORMAT"
+)
+
+// config 日志配置
+type config struct {
+	level     logrus.Level
+	format    Format
+	caller    CallerMode
+	formatter logrus.Formatter
+	outputs   []io.Writer
+	files     []*RotateWriter
+	hooks     []logrus.Hook
+	fields    logrus.Fields
+	redactor  *Redactor
+}
+
+// Option 日志选项
+type Option func(*config)
+
+// WithLevel 设置日志级别, 默认 DebugLevel
+func WithLevel(level logrus.Level) Option {
+	return func(c *config) {
+		c.level = level
+	}
+}
+
+// WithFormat 设置输出格式
+func WithFormat(format Format) Option {
+	return func(c *config) {
+		c.format = format
+	}
+}
+
+// WithFormatter 设置自定义格式, 优先级高于 WithFormat
+func WithFormatter(formatter logrus.Formatter) Option {
+	return func(c *config) {
+		c.formatter = formatter
+	}
+}
+
+// WithCaller 设置调用位置的显示方式
+func WithCaller(mode CallerMode) Option {
+	return func(c *config) {
+		c.caller = mode
+	}
+}
+
+// WithOutput 设置输出, 默认 os.Stderr. 多个输出时第一个为主输出, 其余输出不带颜色
+func WithOutput(outputs ...io.Writer) Option {
+	return func(c *config) {
+		c.outputs = outputs
+	}
+}
+
+// WithRotateWriter 在控制台之外同时写入轮转文件, 文件中不输出颜色.
+// w 由调用方创建和关闭, 重新 Configure 后旧的 w 不再被使用, 可以安全关闭
+//
+//	w := logs.NewRotateWriter("logs/app.log", logs.WithDaily())
+//	defer w.Close()
+//	logs.Configure(logs.WithRotateWriter(w))
+func WithRotateWriter(w *RotateWriter) Option {
+	return func(c *config) {
+		if w != nil {
+			c.files = append(c.files, w)
+		}
+	}
+}
+
+// WithHooks 添加 hook
+func WithHooks(hooks ...logrus.Hook) Option {
+	return func(c *config) {
+		c.hooks = append(c.hooks, hooks...)
+	}
+}
+
+// WithFields 每条日志都附带的字段, 同名字段以日志调用时的为准
+func WithFields(fields logrus.Fields) Option {
+	return func(c *config) {
+		if c.fields == nil {
+			c.fields = make(logrus.Fields, len(fields))
+		}
+		for k, v := range fields {
+			c.fields[k] = v
+		}
+	}
+}
+
+// WithRedactor 设置敏感信息隐藏规则, 默认 NewRedactor(MaskFull), 为 nil 时不隐藏
+func WithRedactor(r *Redactor) Option {
+	return func(c *config) {
+		c.redactor = r
+	}
+}
+
+func newConfig(opts ...Option) *config {
+	cfg := &config{level: logrus.DebugLevel, format: FormatText, redactor: NewRedactor(MaskFull)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if name := os.Getenv(EnvLevel); name != "" {
+		if level, err := logrus.ParseLevel(name); err == nil {
+			cfg.level = level
+		} else {
+			fmt.Fprintf(os.Stderr, "logs: invalid %s: %v\n", EnvLevel, err)
+		}
+	}
+	if name := os.Getenv(EnvFormat); name != "" {
+		if format, err := ParseFormat(name); err == nil {
+			cfg.format, cfg.formatter = format, nil
+		} else {
+			fmt.Fprintf(os.Stderr, "logs: invalid %s: %v\n", EnvFormat, err)
+		}
+	}
+
+	if cfg.formatter == nil {
+		cfg.formatter = NewFormatter(cfg.format, cfg.caller)
+	}
+	if len(cfg.outputs) == 0 {
+		cfg.outputs = []io.Writer{os.Stderr}
+	}
+	return cfg
+}
+
+// apply 将配置应用到 logger, 会替换 logger 原有的 hook
+func (c *config) apply(logger *logrus.Logger) {
+	hooks := make(logrus.LevelHooks)
+	// 公共字段需要在其他 hook 之前添加
+	if len(c.fields) > 0 {
+		hooks.Add(fieldsHook(c.fields))
+	}
+	// 隐藏敏感信息需要在输出之前
+	if c.redactor != nil {
+		hooks.Add(c.redactor)
+	}
+	for _, w := range c.outputs[1:] {
+		hooks.Add(NewWriterHook(w, plainFormatter(c.formatter)))
+	}
+	for _, file := range c.files {
+		hooks.Add(NewWriterHook(file, plainFormatter(c.formatter)))
+	}
+	for _, hook := range c.hooks {
+		hooks.Add(hook)
+	}
+
+	logger.SetFormatter(c.formatter)
+	logger.SetLevel(c.level)
+	logger.SetOutput(c.outputs[0])
+	logger.ReplaceHooks(hooks)
+}
+
+// fieldsHook 为每条日志添加公共字段
+type fieldsHook logrus.Fields
+
+func (h fieldsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h fieldsHook) Fire(entry *logrus.Entry) error {
+	for k, v := range h {
+		if _, ok := entry.Data[k]; !ok {
+			entry.Data[k] = v
+		}
+	}
+	return nil
+}
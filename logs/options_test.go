@@ -0,0 +1,72 @@
+package logs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestInitLoggerDefaults(t *testing.T) {
+	logger := InitLogger()
+	if logger.Level != logrus.DebugLevel {
+		t.Errorf("level = %v", logger.Level)
+	}
+	if _, ok := logger.Formatter.(*CustomText); !ok {
+		t.Errorf("formatter = %T", logger.Formatter)
+	}
+}
+
+func TestLevelEnv(t *testing.T) {
+	logger := InitLogger(WithLevel(logrus.InfoLevel))
+	if logger.Level != logrus.InfoLevel {
+		t.Errorf("level = %v", logger.Level)
+	}
+
+	t.Setenv(EnvLevel, "warn")
+	logger = InitLogger(WithLevel(logrus.InfoLevel))
+	if logger.Level != logrus.WarnLevel {
+		t.Errorf("expected LOG_LEVEL to override option, got %v", logger.Level)
+	}
+}
+
+func TestOutputsHooksFields(t *testing.T) {
+	var main, extra bytes.Buffer
+	hook := test.NewLocal(logrus.New())
+	logger := InitLogger(
+		WithOutput(&main, &extra),
+		WithFormatter(&CustomText{ForceColors: true}),
+		WithHooks(hook),
+		WithFields(logrus.Fields{"app": "demo", "env": "dev"}),
+	)
+	logger.WithField("env", "prod").Info("hello")
+
+	if !strings.Contains(main.String(), "app=demo env=prod") {
+		t.Errorf("main output = %q", main.String())
+	}
+	if !strings.Contains(extra.String(), "hello") || strings.Contains(extra.String(), "\x1b[") {
+		t.Errorf("extra output = %q", extra.String())
+	}
+	if entry := hook.LastEntry(); entry == nil || entry.Data["app"] != "demo" {
+		t.Errorf("hook entry = %+v", entry)
+	}
+}
+
+func TestConfigureDefault(t *testing.T) {
+	logger := Default()
+	defer Configure()
+
+	var buf bytes.Buffer
+	Configure(WithOutput(&buf), WithLevel(logrus.ErrorLevel))
+	if Default() != logger {
+		t.Fatal("Configure must keep the default logger instance")
+	}
+
+	logger.Info("dropped")
+	logger.Error("kept")
+	if out := buf.String(); strings.Contains(out, "dropped") || !strings.Contains(out, "kept") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
@@ -0,0 +1,244 @@
+package logs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MaskStyle 敏感值的隐藏方式
+type MaskStyle int
+
+const (
+	// MaskFull 整体替换为 ******
+	MaskFull MaskStyle = iota
+	// MaskPartial 保留首尾少量字符, 较短的值整体替换
+	MaskPartial
+	// MaskHash 替换为 sha256 前缀, 同一个值的结果相同, 便于关联排查
+	MaskHash
+)
+
+// Rule 按正则匹配敏感内容
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Group 需要隐藏的子匹配序号, 0 表示整个匹配
+	Group int
+	// Valid 进一步校验匹配内容, 为空时总是隐藏
+	Valid func(s string) bool
+}
+
+// Redactor 隐藏日志中的敏感信息, 作为第一个 hook 运行,
+// 之后的所有格式和 hook 只能看到隐藏后的消息和字段
+type Redactor struct {
+	// Keys 敏感字段名, 字段名与其中一项相同, 或以 _、.、- 分隔的最后几段与其相同时隐藏整个值,
+	// 不区分大小写, 驼峰按单词拆分. 例如 password 匹配 db_password、user.password、userPassword,
+	// 不匹配 passwordless、password_hint
+	Keys []string
+	// Rules 对消息、字段值和错误信息按顺序应用
+	Rules []Rule
+	// Style 隐藏方式, 默认 MaskFull
+	Style MaskStyle
+}
+
+// DefaultKeys 默认的敏感字段名
+var DefaultKeys = []string{
+	"password", "passwd", "pwd", "secret", "token", "authorization",
+	"cookie", "api_key", "apikey", "access_key", "secret_key", "private_key", "credential",
+	"card_no", "cardno", "card_number", "cardnumber",
+}
+
+// DefaultRules 默认规则, 依次处理 JWT、Bearer Token、URL 和 DSN 中的密码、
+// key=value 形式的密钥(包括 MYSQL_ROOT_PASSWORD=xxx 这类环境变量)、银行卡号和邮箱.
+// 银行卡号只匹配带空格或 - 分隔的号码, 以及紧跟在 card、卡号 后面的号码,
+// 避免把时间戳、雪花 ID 等普通数字误判为卡号
+func DefaultRules() []Rule {
+	return []Rule{
+		{Name: "jwt", Pattern: regexp.MustCompile(`eyJ[\w-]+\.eyJ[\w-]+\.[\w-]+`)},
+		{Name: "bearer", Pattern: regexp.MustCompile(`(?i)\bbearer\s+([\w.~+/-]+=*)`), Group: 1},
+		{Name: "url", Pattern: regexp.MustCompile(`[a-zA-Z][\w+.-]*://[^:/@\s]*:([^@\s/]+)@`), Group: 1},
+		{Name: "dsn", Pattern: regexp.MustCompile(`[\w.-]+:([^@\s/:]+)@(?:tcp|udp|unix)\(`), Group: 1},
+		{
+			Name:    "secret",
+			Pattern: regexp.MustCompile(`(?i)[\w.-]*(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key)[\w.-]*["']?\s*[=:]\s*["']?([^\s"'&,;)\]}]+)`),
+			Group:   1,
+		},
+		{Name: "card", Pattern: regexp.MustCompile(`\b(?:\d{4}[ -]\d{6}[ -]\d{5}|\d{4}(?:[ -]\d{4}){3}(?:[ -]\d{3})?)\b`), Valid: luhn},
+		{Name: "card_context", Pattern: regexp.MustCompile(`(?i)(?:card|卡号)\w*["']?[\s:=#：]{0,3}["']?(\d{13,19})\b`), Group: 1, Valid: luhn},
+		{Name: "email", Pattern: regexp.MustCompile(`[\w.%+-]+@[\w-]+(?:\.[\w-]+)*\.[a-zA-Z]{2,}`)},
+	}
+}
+
+// NewRedactor 使用默认字段名和规则创建 Redactor
+func NewRedactor(style MaskStyle) *Redactor {
+	return &Redactor{Keys: DefaultKeys, Rules: DefaultRules(), Style: style}
+}
+
+// Redact 按规则隐藏字符串中的敏感内容
+func (r *Redactor) Redact(s string) string {
+	for _, rule := range r.Rules {
+		s = r.apply(rule, s)
+	}
+	return s
+}
+
+func (r *Redactor) apply(rule Rule, s string) string {
+	matches := rule.Pattern.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[2*rule.Group], m[2*rule.Group+1]
+		if start < 0 || (rule.Valid != nil && !rule.Valid(s[start:end])) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(r.Mask(s[start:end]))
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// Mask 按 Style 隐藏整个值
+func (r *Redactor) Mask(s string) string {
+	switch r.Style {
+	case MaskPartial:
+		// 保留首尾各 1/8, 最多 4 个字符
+		n := utf8.RuneCountInString(s)
+		if keep := min(n/8, 4); keep > 0 {
+			runes := []rune(s)
+			return string(runes[:keep]) + "****" + string(runes[n-keep:])
+		}
+	case MaskHash:
+		sum := sha256.Sum256([]byte(s))
+		return "sha256:" + hex.EncodeToString(sum[:4])
+	}
+	return "******"
+}
+
+// sensitiveKey 字段名是否敏感, 见 Redactor.Keys
+func (r *Redactor) sensitiveKey(key string) bool {
+	key = normalizeKey(key)
+	for _, k := range r.Keys {
+		k = normalizeKey(k)
+		if k != "" && (key == k || strings.HasSuffix(key, "_"+k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeKey 转为小写, 分隔符统一为 _, 驼峰在单词间加 _, 例如 X-Api-Key、apiKey 都转为 api_key
+func normalizeKey(key string) string {
+	var b strings.Builder
+	var prev rune
+	for _, c := range key {
+		if c == '.' || c == '-' {
+			c = '_'
+		}
+		if unicode.IsUpper(c) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+			b.WriteByte('_')
+		}
+		prev = c
+		b.WriteRune(unicode.ToLower(c))
+	}
+	return b.String()
+}
+
+// Value 隐藏字段值, 不改变原值.
+// 敏感字段名整体隐藏; 字符串、错误和 fmt.Stringer 按规则处理; 数字和布尔值原样保留,
+// 其他类型按 fmt.Sprint 的结果处理, 命中规则时替换为字符串
+func (r *Redactor) Value(key string, v any) any {
+	if v == nil {
+		return v
+	}
+	if r.sensitiveKey(key) {
+		return r.Mask(fmt.Sprint(fieldValue(v)))
+	}
+	switch v := v.(type) {
+	case string:
+		return r.Redact(v)
+	case error:
+		return redactedError{v, r}
+	case fmt.Stringer:
+		if s := v.String(); r.Redact(s) != s {
+			return r.Redact(s)
+		}
+		return v
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return v
+	}
+	if s := fmt.Sprint(v); r.Redact(s) != s {
+		return r.Redact(s)
+	}
+	return v
+}
+
+func (r *Redactor) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 原地修改 entry, logrus 在触发 hook 前已复制 entry 和字段
+func (r *Redactor) Fire(entry *logrus.Entry) error {
+	entry.Message = r.Redact(entry.Message)
+	for key, value := range entry.Data {
+		entry.Data[key] = r.Value(key, value)
+	}
+	return nil
+}
+
+// redactedError 隐藏错误信息和 %+v 输出的堆栈
+type redactedError struct {
+	err error
+	r   *Redactor
+}
+
+func (e redactedError) Error() string {
+	return e.r.Redact(e.err.Error())
+}
+
+func (e redactedError) Unwrap() error {
+	return e.err
+}
+
+func (e redactedError) Format(s fmt.State, verb rune) {
+	if verb == 'v' && s.Flag('+') {
+		fmt.Fprint(s, e.r.Redact(fmt.Sprintf("%+v", e.err)))
+		return
+	}
+	fmt.Fprint(s, e.Error())
+}
+
+// luhn 校验银行卡号, 减少把普通数字误判为卡号
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
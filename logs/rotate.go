@@ -0,0 +1,413 @@
+package logs
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 备份文件名中的时间格式, 例如 app-2006-01-02T15-04-05.000.log, 时间为备份内容的开始时间,
+// 同名备份已存在时加序号, 例如 app-2006-01-02T15-04-05.000-1.log
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+const compressSuffix = ".gz"
+
+// RotateOption 文件轮转选项
+type RotateOption func(*RotateWriter)
+
+// WithMaxSize 单个文件的最大字节数, 超过后轮转, 0 表示不限制
+func WithMaxSize(size int64) RotateOption {
+	return func(w *RotateWriter) {
+		w.maxSize = size
+	}
+}
+
+// WithDaily 每天轮转一次
+func WithDaily() RotateOption {
+	return func(w *RotateWriter) {
+		w.daily = true
+	}
+}
+
+// WithMaxBackups 最多保留的备份数量, 0 表示不限制
+func WithMaxBackups(n int) RotateOption {
+	return func(w *RotateWriter) {
+		w.maxBackups = n
+	}
+}
+
+// WithMaxAge 备份最长保留天数, 0 表示不限制
+func WithMaxAge(days int) RotateOption {
+	return func(w *RotateWriter) {
+		w.maxAge = time.Duration(days) * 24 * time.Hour
+	}
+}
+
+// WithCompress 使用 gzip 压缩备份
+func WithCompress() RotateOption {
+	return func(w *RotateWriter) {
+		w.compress = true
+	}
+}
+
+// WithReopenSignal 收到 SIGHUP 时重新打开文件, 配合外部 logrotate 使用
+func WithReopenSignal() RotateOption {
+	return func(w *RotateWriter) {
+		w.reopenSignal = true
+	}
+}
+
+// RotateWriter 按大小和日期轮转的日志文件, 首次写入时打开文件
+type RotateWriter struct {
+	filename     string
+	maxSize      int64
+	daily        bool
+	maxBackups   int
+	maxAge       time.Duration
+	compress     bool
+	reopenSignal bool
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	start  time.Time // 当前文件的开始时间, 用于命名备份
+	day    string
+	closed bool
+	now    func() time.Time
+
+	millCh  chan struct{}
+	signals chan os.Signal
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewRotateWriter 创建轮转文件
+func NewRotateWriter(filename string, opts ...RotateOption) *RotateWriter {
+	w := &RotateWriter{
+		filename: filename,
+		now:      time.Now,
+		millCh:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	w.wg.Add(1)
+	go w.millLoop()
+
+	if w.reopenSignal {
+		w.signals = make(chan os.Signal, 1)
+		signal.Notify(w.signals, syscall.SIGHUP)
+		w.wg.Add(1)
+		go w.signalLoop()
+	}
+	return w
+}
+
+// Filename 当前写入的文件
+func (w *RotateWriter) Filename() string {
+	return w.filename
+}
+
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file == nil {
+		if err := w.openExisting(); err != nil {
+			return 0, err
+		}
+	}
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate 立即轮转
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+// Reopen 关闭并重新打开文件, 文件被外部移走后会创建新文件
+func (w *RotateWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return os.ErrClosed
+	}
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+	return w.openExisting()
+}
+
+// Close 关闭文件并等待后台压缩和清理完成
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	err := w.closeFile()
+	w.mu.Unlock()
+
+	if w.signals != nil {
+		signal.Stop(w.signals)
+	}
+	close(w.done)
+	w.wg.Wait()
+	return err
+}
+
+func (w *RotateWriter) shouldRotate(n int64) bool {
+	if w.maxSize > 0 && w.size > 0 && w.size+n > w.maxSize {
+		return true
+	}
+	return w.daily && w.day != w.now().Format(time.DateOnly)
+}
+
+// openExisting 追加打开文件, 不存在时创建
+func (w *RotateWriter) openExisting() error {
+	if err := os.MkdirAll(filepath.Dir(w.filename), 0o755); err != nil {
+		return fmt.Errorf("logs: create log dir: %w", err)
+	}
+	f, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("logs: open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file, w.size = f, info.Size()
+	// 已有文件的开始时间未知, 按修改时间确定所属日期, 跨天后首次写入会触发轮转
+	w.start = w.now()
+	if info.Size() > 0 {
+		w.start = info.ModTime()
+	}
+	w.day = w.start.Format(time.DateOnly)
+	return nil
+}
+
+// rotate 将当前文件重命名为备份并创建新文件
+func (w *RotateWriter) rotate() error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(w.filename); err == nil {
+		if err := os.Rename(w.filename, w.backupName(w.start)); err != nil {
+			return fmt.Errorf("logs: rotate log file: %w", err)
+		}
+	}
+	if err := w.openExisting(); err != nil {
+		return err
+	}
+
+	select {
+	case w.millCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (w *RotateWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// backupName 开始时间为 t 的备份文件名, 已存在(包括压缩后的)时加序号, 避免覆盖
+func (w *RotateWriter) backupName(t time.Time) string {
+	dir, prefix, ext := w.nameParts()
+	stamp := t.Format(backupTimeFormat)
+	name := filepath.Join(dir, prefix+stamp+ext)
+	for seq := 1; exists(name) || exists(name+compressSuffix); seq++ {
+		name = filepath.Join(dir, fmt.Sprintf("%s%s-%d%s", prefix, stamp, seq, ext))
+	}
+	return name
+}
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// nameParts app.log 拆分为目录、前缀 app- 和扩展名 .log
+func (w *RotateWriter) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(w.filename)
+	base := filepath.Base(w.filename)
+	ext = filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+type backup struct {
+	path string
+	time time.Time
+	seq  int
+}
+
+// backups 按时间倒序返回所有备份
+func (w *RotateWriter) backups() ([]backup, error) {
+	dir, prefix, ext := w.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimPrefix(name, prefix)
+		stamp = strings.TrimSuffix(stamp, compressSuffix)
+		if !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		t, seq, ok := parseBackupStamp(strings.TrimSuffix(stamp, ext))
+		if !ok {
+			continue
+		}
+		out = append(out, backup{path: filepath.Join(dir, name), time: t, seq: seq})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].time.Equal(out[j].time) {
+			return out[i].time.After(out[j].time)
+		}
+		return out[i].seq > out[j].seq
+	})
+	return out, nil
+}
+
+// parseBackupStamp 解析备份文件名中的时间和序号, 例如 2006-01-02T15-04-05.000-1
+func parseBackupStamp(stamp string) (time.Time, int, bool) {
+	if len(stamp) < len(backupTimeFormat) {
+		return time.Time{}, 0, false
+	}
+	t, err := time.ParseInLocation(backupTimeFormat, stamp[:len(backupTimeFormat)], time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	rest := stamp[len(backupTimeFormat):]
+	if rest == "" {
+		return t, 0, true
+	}
+	seq, err := strconv.Atoi(strings.TrimPrefix(rest, "-"))
+	if !strings.HasPrefix(rest, "-") || err != nil || seq <= 0 {
+		return time.Time{}, 0, false
+	}
+	return t, seq, true
+}
+
+func (w *RotateWriter) millLoop() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.millCh:
+			w.mill()
+		case <-w.done:
+			// 处理关闭前最后一次轮转
+			select {
+			case <-w.millCh:
+				w.mill()
+			default:
+			}
+			return
+		}
+	}
+}
+
+func (w *RotateWriter) signalLoop() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.signals:
+			if err := w.Reopen(); err != nil {
+				fmt.Fprintf(os.Stderr, "logs: reopen %s: %v\n", w.filename, err)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// mill 清理过期和多余的备份, 压缩剩余的备份
+func (w *RotateWriter) mill() {
+	backups, err := w.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logs: list backups: %v\n", err)
+		return
+	}
+
+	cutoff := w.now().Add(-w.maxAge)
+	for i, b := range backups {
+		expired := w.maxAge > 0 && b.time.Before(cutoff)
+		if expired || (w.maxBackups > 0 && i >= w.maxBackups) {
+			os.Remove(b.path)
+			continue
+		}
+		if w.compress && !strings.HasSuffix(b.path, compressSuffix) {
+			if err := compressFile(b.path); err != nil {
+				fmt.Fprintf(os.Stderr, "logs: compress %s: %v\n", b.path, err)
+			}
+		}
+	}
+}
+
+// compressFile 压缩为 .gz 文件并删除原文件
+func compressFile(src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	dst := src + compressSuffix
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err == nil {
+		err = gz.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
@@ -0,0 +1,223 @@
+package logs
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct{ ns atomic.Int64 }
+
+func newFakeClock(t time.Time) *fakeClock {
+	c := &fakeClock{}
+	c.ns.Store(t.UnixNano())
+	return c
+}
+
+func (c *fakeClock) Now() time.Time      { return time.Unix(0, c.ns.Load()) }
+func (c *fakeClock) Add(d time.Duration) { c.ns.Add(int64(d)) }
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Errorf("read %s: %v", filepath.Base(name), err)
+	}
+	return string(b)
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestRotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock(time.Now())
+	w := NewRotateWriter(filepath.Join(dir, "app.log"), WithMaxSize(10), WithMaxBackups(2))
+	w.now = clock.Now
+
+	for _, line := range []string{"line-1\n", "line-2\n", "line-3\n", "line-4\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		clock.Add(time.Second)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, filepath.Join(dir, "app.log")); got != "line-4\n" {
+		t.Errorf("current file = %q", got)
+	}
+	// 共轮转 3 次, 只保留最新的 2 个备份
+	names := listDir(t, dir)
+	if len(names) != 3 {
+		t.Fatalf("expected 1 file and 2 backups, got %v", names)
+	}
+	if !strings.HasPrefix(names[0], "app-") || !strings.HasSuffix(names[0], ".log") {
+		t.Errorf("unexpected backup name %q", names[0])
+	}
+	if got := readFile(t, filepath.Join(dir, names[1])); got != "line-3\n" {
+		t.Errorf("newest backup = %q", got)
+	}
+}
+
+func TestRotateDailyCompress(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock(time.Date(2024, 1, 1, 23, 59, 0, 0, time.Local))
+	w := NewRotateWriter(filepath.Join(dir, "app.log"), WithDaily(), WithCompress())
+	w.now = clock.Now
+
+	w.Write([]byte("day-1\n"))
+	clock.Add(2 * time.Minute)
+	w.Write([]byte("day-2\n"))
+	w.Close()
+
+	// 备份按内容的开始时间命名, 日期为前一天
+	gz := filepath.Join(dir, "app-2024-01-01T23-59-00.000.log.gz")
+	f, err := os.Open(gz)
+	if err != nil {
+		t.Fatalf("expected compressed backup, got %v", listDir(t, dir))
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(r); string(b) != "day-1\n" {
+		t.Errorf("backup content = %q", b)
+	}
+	if got := readFile(t, filepath.Join(dir, "app.log")); got != "day-2\n" {
+		t.Errorf("current file = %q", got)
+	}
+}
+
+func TestRotateSameTime(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local))
+	w := NewRotateWriter(filepath.Join(dir, "app.log"), WithMaxBackups(2))
+	w.now = clock.Now
+
+	// 同一时刻多次轮转, 备份不能互相覆盖
+	for _, line := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		w.Write([]byte(line))
+		if err := w.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	want := map[string]string{
+		"app-2024-01-01T12-00-00.000-2.log": "c\n",
+		"app-2024-01-01T12-00-00.000-3.log": "d\n",
+	}
+	names := listDir(t, dir)
+	if len(names) != 3 {
+		t.Fatalf("expected 1 file and 2 backups, got %v", names)
+	}
+	for name, content := range want {
+		if got := readFile(t, filepath.Join(dir, name)); got != content {
+			t.Errorf("%s = %q, want %q", name, got, content)
+		}
+	}
+}
+
+func TestRotateMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	old := filepath.Join(dir, "app-"+now.AddDate(0, 0, -10).Format(backupTimeFormat)+".log.gz")
+	recent := filepath.Join(dir, "app-"+now.AddDate(0, 0, -1).Format(backupTimeFormat)+".log")
+	other := filepath.Join(dir, "other.log")
+	for _, name := range []string{old, recent, other} {
+		os.WriteFile(name, []byte("x"), 0o644)
+	}
+
+	w := NewRotateWriter(filepath.Join(dir, "app.log"), WithMaxAge(7))
+	w.Write([]byte("x"))
+	w.Rotate()
+	w.Close()
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("expected expired backup to be removed")
+	}
+	for _, name := range []string{recent, other} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("expected %s to be kept", filepath.Base(name))
+		}
+	}
+}
+
+func TestRotateReopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.log")
+	w := NewRotateWriter(name)
+	defer w.Close()
+
+	w.Write([]byte("before\n"))
+	// 模拟外部 logrotate 移走文件
+	os.Rename(name, name+".1")
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("after\n"))
+
+	if got := readFile(t, name); got != "after\n" {
+		t.Errorf("reopened file = %q", got)
+	}
+	if got := readFile(t, name+".1"); got != "before\n" {
+		t.Errorf("moved file = %q", got)
+	}
+}
+
+func TestInitLoggerWithRotateWriter(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "logs", "app.log")
+	w := NewRotateWriter(name)
+	defer w.Close()
+	logger, console := newTestLogger(WithRotateWriter(w), WithFormatter(&CustomText{ForceColors: true}))
+	logger.Info("hello")
+
+	if !strings.Contains(console.String(), "\x1b[") {
+		t.Errorf("expected colored console output: %q", console)
+	}
+	got := readFile(t, name)
+	if !strings.Contains(got, "hello") || strings.Contains(got, "\x1b[") {
+		t.Errorf("unexpected file output: %q", got)
+	}
+}
+
+func TestReconfigureRotateWriter(t *testing.T) {
+	dir := t.TempDir()
+	first, second := NewRotateWriter(filepath.Join(dir, "a.log")), NewRotateWriter(filepath.Join(dir, "b.log"))
+	defer second.Close()
+
+	logger, _ := newTestLogger(WithRotateWriter(first))
+	logger.Info("one")
+	newConfig(WithRotateWriter(second)).apply(logger)
+	// 重新配置后旧的文件由调用方关闭, 不再被写入
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("two")
+
+	if got := readFile(t, filepath.Join(dir, "a.log")); !strings.Contains(got, "one") || strings.Contains(got, "two") {
+		t.Errorf("first file = %q", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "b.log")); !strings.Contains(got, "two") {
+		t.Errorf("second file = %q", got)
+	}
+}
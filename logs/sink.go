@@ -0,0 +1,273 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// Record 发送到外部的日志, 可以序列化为 JSON 保存到 spool
+type Record struct {
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level"`
+	Message string         `json:"msg"`
+	Caller  string         `json:"caller,omitempty"`
+	Fields  map[string]any `json:"fields,omitempty"`
+	// Repeated 上一次发送后因去重被忽略的相同日志条数
+	Repeated int `json:"repeated,omitempty"`
+}
+
+// newRecord 从 entry 复制数据, hook 异步发送时 entry 可能已被复用
+func newRecord(entry *logrus.Entry) Record {
+	r := Record{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+		Caller:  callerInfo(entry, CallerShort),
+	}
+	if data := entryData(entry); len(data) > 0 {
+		r.Fields = make(map[string]any, len(data))
+		for key, value := range data {
+			// 保证能序列化为 JSON
+			value = fieldValue(value)
+			if _, err := json.Marshal(value); err != nil {
+				value = fmt.Sprint(value)
+			}
+			r.Fields[key] = value
+		}
+	}
+	return r
+}
+
+// Text 单行文本, 用于聊天机器人和 syslog
+func (r Record) Text() string {
+	var b strings.Builder
+	b.WriteString(r.Message)
+	keys := make([]string, 0, len(r.Fields))
+	for key := range r.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%v", key, r.Fields[key])
+	}
+	if r.Caller != "" {
+		fmt.Fprintf(&b, " (%s)", r.Caller)
+	}
+	if r.Repeated > 0 {
+		fmt.Fprintf(&b, " [repeated %d times]", r.Repeated)
+	}
+	return b.String()
+}
+
+// Sink 外部日志接收端, Fatal 和 Panic 日志会在调用方的协程中发送, Send 需要支持并发调用
+type Sink interface {
+	Send(ctx context.Context, r Record) error
+}
+
+// SinkHook 将日志异步发送到 Sink, 默认只处理 Warn 及以上级别.
+// 发送队列满或超出限速时丢弃日志, 不会阻塞调用方.
+// Fatal 和 Panic 日志之后进程会退出, 因此不限速并同步发送, 发送前先等待队列中的日志发送完成
+type SinkHook struct {
+	sink    Sink
+	levels  []logrus.Level
+	timeout time.Duration
+	limiter *rate.Limiter
+	window  time.Duration
+
+	mu     sync.Mutex
+	seen   map[string]*dedupState
+	queue  chan sinkItem
+	closed bool
+
+	dropped atomic.Uint64
+	done    chan struct{}
+}
+
+// sinkItem 发送队列中的一项, flushed 不为空时不是日志, 而是通知 sendNow 之前的日志已发送
+type sinkItem struct {
+	record  Record
+	flushed chan struct{}
+}
+
+// dedupState 去重窗口内的状态
+type dedupState struct {
+	first      time.Time
+	suppressed int
+}
+
+// SinkOption SinkHook 选项
+type SinkOption func(*SinkHook)
+
+// WithSinkLevels 处理的级别, 默认 Panic~Warn
+func WithSinkLevels(levels ...logrus.Level) SinkOption {
+	return func(h *SinkHook) {
+		h.levels = levels
+	}
+}
+
+// WithRateLimit 每 per 时间内最多发送 n 条, 超出的丢弃
+func WithRateLimit(n int, per time.Duration) SinkOption {
+	return func(h *SinkHook) {
+		h.limiter = rate.NewLimiter(rate.Every(per/time.Duration(max(n, 1))), max(n, 1))
+	}
+}
+
+// WithDedup 窗口内级别和消息都相同的日志只发送一次,
+// 窗口结束后的下一条会带上被忽略的条数
+func WithDedup(window time.Duration) SinkOption {
+	return func(h *SinkHook) {
+		h.window = window
+	}
+}
+
+// WithSendTimeout 单次发送的超时时间, 默认 5s
+func WithSendTimeout(d time.Duration) SinkOption {
+	return func(h *SinkHook) {
+		h.timeout = d
+	}
+}
+
+// WithQueueSize 发送队列长度, 默认 256
+func WithQueueSize(n int) SinkOption {
+	return func(h *SinkHook) {
+		h.queue = make(chan sinkItem, n)
+	}
+}
+
+// NewSinkHook 创建 hook 并启动后台发送协程
+func NewSinkHook(sink Sink, opts ...SinkOption) *SinkHook {
+	h := &SinkHook{
+		sink:    sink,
+		levels:  []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel, logrus.WarnLevel},
+		timeout: 5 * time.Second,
+		seen:    make(map[string]*dedupState),
+		queue:   make(chan sinkItem, 256),
+		done:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	go h.run()
+	return h
+}
+
+func (h *SinkHook) Levels() []logrus.Level {
+	return h.levels
+}
+
+func (h *SinkHook) Fire(entry *logrus.Entry) error {
+	r := newRecord(entry)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed || !h.dedup(&r) {
+		return nil
+	}
+	if entry.Level <= logrus.FatalLevel {
+		h.sendNow(r)
+		return nil
+	}
+	if h.limiter != nil && !h.limiter.Allow() {
+		h.dropped.Add(1)
+		return nil
+	}
+	select {
+	case h.queue <- sinkItem{record: r}:
+	default:
+		h.dropped.Add(1)
+	}
+	return nil
+}
+
+// dedup 返回是否需要发送, 调用时需持有锁
+func (h *SinkHook) dedup(r *Record) bool {
+	if h.window <= 0 {
+		return true
+	}
+	key := r.Level + "\x00" + r.Message
+	now := time.Now()
+	if s, ok := h.seen[key]; ok && now.Sub(s.first) < h.window {
+		s.suppressed++
+		return false
+	} else if ok {
+		r.Repeated = s.suppressed
+	}
+	// 清理过期的状态, 避免消息种类很多时无限增长
+	if len(h.seen) >= 1024 {
+		for k, s := range h.seen {
+			if now.Sub(s.first) >= h.window {
+				delete(h.seen, k)
+			}
+		}
+	}
+	h.seen[key] = &dedupState{first: now}
+	return true
+}
+
+// Dropped 因队列满或限速被丢弃的条数
+func (h *SinkHook) Dropped() uint64 {
+	return h.dropped.Load()
+}
+
+// Close 发送队列中剩余的日志后停止, Sink 实现了 io.Closer 时一并关闭
+func (h *SinkHook) Close() error {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.queue)
+	}
+	h.mu.Unlock()
+
+	<-h.done
+	if c, ok := h.sink.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// sendNow 等待队列中已有的日志发送完成后同步发送 r, 调用时需持有锁.
+// 持有锁保证等待期间没有新的日志进入队列
+func (h *SinkHook) sendNow(r Record) {
+	flushed := make(chan struct{})
+	select {
+	case h.queue <- sinkItem{flushed: flushed}:
+		select {
+		case <-flushed:
+		case <-time.After(h.timeout):
+		}
+	default:
+		// 队列已满, 不再等待
+	}
+	h.send(r)
+}
+
+func (h *SinkHook) run() {
+	defer close(h.done)
+	for item := range h.queue {
+		if item.flushed != nil {
+			close(item.flushed)
+			continue
+		}
+		h.send(item.record)
+	}
+}
+
+func (h *SinkHook) send(r Record) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	// 发送失败不能再写日志, 否则可能循环触发 hook
+	if err := h.sink.Send(ctx, r); err != nil {
+		fmt.Fprintf(os.Stderr, "logs: sink: %v\n", err)
+	}
+}
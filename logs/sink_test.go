@@ -0,0 +1,248 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordSink 保存收到的日志
+type recordSink struct {
+	mu      sync.Mutex
+	records []Record
+	err     error
+}
+
+func (s *recordSink) Send(_ context.Context, r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.records = append(s.records, r)
+	return nil
+}
+
+func (s *recordSink) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+func (s *recordSink) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msgs := make([]string, len(s.records))
+	for i, r := range s.records {
+		msgs[i] = r.Message
+	}
+	return msgs
+}
+
+func TestWebhookSink(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+		if r.URL.Path == "/dingtalk" {
+			io.WriteString(w, `{"errcode":310000,"errmsg":"keywords not in content"}`)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	r := Record{Time: time.Now(), Level: "error", Message: "db down", Fields: map[string]any{"host": "db1"}}
+	for path, style := range map[string]WebhookStyle{"/json": WebhookJSON, "/slack": WebhookSlack, "/feishu": WebhookFeishu} {
+		if err := NewWebhookSink(srv.URL+path, style).Send(ctx, r); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+	if err := NewWebhookSink(srv.URL+"/dingtalk", WebhookDingTalk).Send(ctx, r); err == nil || !strings.Contains(err.Error(), "310000") {
+		t.Errorf("expected dingtalk error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var texts []string
+	for _, body := range bodies {
+		switch {
+		case body["msg"] != nil:
+			if body["msg"] != "db down" || body["fields"].(map[string]any)["host"] != "db1" {
+				t.Errorf("unexpected json body %v", body)
+			}
+		case body["text"] != nil && body["msgtype"] == nil:
+			texts = append(texts, body["text"].(string))
+		case body["msg_type"] == "text":
+			texts = append(texts, body["content"].(map[string]any)["text"].(string))
+		case body["msgtype"] == "text":
+			texts = append(texts, body["text"].(map[string]any)["content"].(string))
+		}
+	}
+	if len(texts) != 3 {
+		t.Fatalf("unexpected bodies %v", bodies)
+	}
+	for _, text := range texts {
+		if !strings.HasPrefix(text, "[ERROR]") || !strings.Contains(text, "db down host=db1") {
+			t.Errorf("unexpected text %q", text)
+		}
+	}
+}
+
+func TestSinkHook(t *testing.T) {
+	sink := &recordSink{}
+	hook := NewSinkHook(sink, WithDedup(50*time.Millisecond))
+	logger, _ := newTestLogger(WithHooks(hook))
+
+	logger.Info("ignored")
+	for i := 0; i < 3; i++ {
+		logger.WithField("n", i).Warn("disk full")
+	}
+	logger.Error("other")
+	time.Sleep(60 * time.Millisecond)
+	logger.Warn("disk full")
+	if err := hook.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(sink.messages(), ","); got != "disk full,other,disk full" {
+		t.Fatalf("unexpected messages %q", got)
+	}
+	if last := sink.records[2]; last.Repeated != 2 || !strings.Contains(last.Text(), "repeated 2 times") {
+		t.Errorf("unexpected repeated count %+v", last)
+	}
+	if first := sink.records[0]; first.Fields["n"] != 0 || !strings.HasPrefix(first.Caller, "logs/sink_test.go:") {
+		t.Errorf("unexpected record %+v", first)
+	}
+}
+
+func TestSinkHookRateLimit(t *testing.T) {
+	sink := &recordSink{}
+	hook := NewSinkHook(sink, WithRateLimit(2, time.Hour))
+	logger, _ := newTestLogger(WithHooks(hook))
+	for i := 0; i < 5; i++ {
+		logger.Error("boom")
+	}
+	hook.Close()
+	if n := len(sink.messages()); n != 2 || hook.Dropped() != 3 {
+		t.Errorf("sent %d, dropped %d", n, hook.Dropped())
+	}
+}
+
+func TestSinkHookFatal(t *testing.T) {
+	sink := &recordSink{}
+	hook := NewSinkHook(sink, WithRateLimit(1, time.Hour))
+	defer hook.Close()
+	logger, _ := newTestLogger(WithHooks(hook))
+	var exited bool
+	logger.ExitFunc = func(int) { exited = true }
+
+	logger.Error("before")
+	// Fatal 在 ExitFunc 之前已发送, 不受限速影响
+	logger.Fatal("fatal")
+	if got := strings.Join(sink.messages(), ","); !exited || got != "before,fatal" {
+		t.Fatalf("unexpected messages %q", got)
+	}
+
+	func() {
+		defer func() { recover() }()
+		logger.Panic("panic")
+	}()
+	if got := strings.Join(sink.messages(), ","); got != "before,fatal,panic" {
+		t.Errorf("unexpected messages %q", got)
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skip("unix sockets not supported:", err)
+	}
+	defer conn.Close()
+
+	sink := NewSyslogSink("", path, "app")
+	defer sink.Close()
+	r := Record{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local), Level: "warning", Message: "slow\nquery", Fields: map[string]any{"ms": 120}}
+	if err := sink.Send(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// user facility(1) * 8 + warning(4)
+	got := string(buf[:n])
+	if !strings.HasPrefix(got, "<12>Jan  2 03:04:05 app[") || !strings.HasSuffix(got, "]: slow query ms=120") {
+		t.Errorf("unexpected message %q", got)
+	}
+}
+
+func TestSpoolSink(t *testing.T) {
+	sink := &recordSink{err: errors.New("offline")}
+	path := filepath.Join(t.TempDir(), "spool", "alerts.jsonl")
+	spool, err := NewSpoolSink(path, sink, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, msg := range []string{"a", "b"} {
+		if err := spool.Send(ctx, Record{Level: "error", Message: msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if spool.Pending() != 2 || spool.Retry(ctx) == nil {
+		t.Fatalf("expected 2 pending records, got %d", spool.Pending())
+	}
+	spool.Close()
+
+	// 重启后继续发送, 新日志排在已保存的之后
+	sink.setErr(nil)
+	spool, err = NewSpoolSink(path, sink, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spool.Close()
+	spool.Send(ctx, Record{Level: "error", Message: "c"})
+
+	deadline := time.Now().Add(time.Second)
+	for spool.Pending() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("spool not drained")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := strings.Join(sink.messages(), ""); got != "abc" {
+		t.Errorf("unexpected order %q", got)
+	}
+
+	// interval 为 0 时使用默认值
+	other, err := NewSpoolSink(filepath.Join(t.TempDir(), "other.jsonl"), sink, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Close()
+
+	spool.MaxRecords = 1
+	sink.setErr(errors.New("offline"))
+	spool.Send(ctx, Record{Message: "d"})
+	if err := spool.Send(ctx, Record{Message: "e"}); !errors.Is(err, ErrSpoolFull) {
+		t.Errorf("expected ErrSpoolFull, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package logs
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SlogHandler 将 slog 记录转发到 logrus, 使用 logrus 的格式、级别、输出和 hook
+type SlogHandler struct {
+	logger *logrus.Logger
+	fields logrus.Fields
+	group  string
+}
+
+// NewSlogHandler 创建 slog.Handler, logger 为空时使用 Default()
+func NewSlogHandler(logger *logrus.Logger) *SlogHandler {
+	if logger == nil {
+		logger = Default()
+	}
+	return &SlogHandler{logger: logger, fields: logrus.Fields{}}
+}
+
+// Slog 基于默认日志的 slog.Logger
+func Slog() *slog.Logger {
+	return slog.New(NewSlogHandler(Default()))
+}
+
+// SetSlogDefault 将默认日志设置为 slog 的默认 Logger,
+// 使用 slog.Default() 的包(例如 db/small)和标准库 log 输出会统一格式和级别
+func SetSlogDefault() {
+	slog.SetDefault(Slog())
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.IsLevelEnabled(logrusLevel(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make(logrus.Fields, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, h.group, a)
+		return true
+	})
+
+	entry := logrus.NewEntry(h.logger).WithContext(ctx).WithTime(r.Time).WithFields(fields)
+	entry.Log(logrusLevel(r.Level), r.Message)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(logrus.Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		addAttr(fields, h.group, a)
+	}
+	return &SlogHandler{logger: h.logger, fields: fields, group: h.group}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{logger: h.logger, fields: h.fields, group: h.group + name + "."}
+}
+
+// addAttr 将 slog 属性展开为字段, 分组以点号连接, 例如 req.method
+func addAttr(fields logrus.Fields, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addAttr(fields, prefix, ga)
+		}
+		return
+	}
+	fields[prefix+a.Key] = a.Value.Any()
+}
+
+// logrusLevel slog 级别转换为 logrus 级别
+func logrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level >= slog.LevelError:
+		return logrus.ErrorLevel
+	case level >= slog.LevelWarn:
+		return logrus.WarnLevel
+	case level >= slog.LevelInfo:
+		return logrus.InfoLevel
+	case level >= slog.LevelDebug:
+		return logrus.DebugLevel
+	}
+	return logrus.TraceLevel
+}
+
+// slogLevel logrus 级别转换为 slog 级别
+func slogLevel(level logrus.Level) slog.Level {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		return slog.LevelError
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.DebugLevel:
+		return slog.LevelDebug
+	}
+	return slog.LevelDebug - 4
+}
+
+// SlogHook 将 logrus 日志转发到 slog.Handler
+type SlogHook struct {
+	handler slog.Handler
+}
+
+// NewSlogHook 创建转发 hook, 只需要 slog 输出时可将 logger 的输出设置为 io.Discard
+func NewSlogHook(handler slog.Handler) *SlogHook {
+	return &SlogHook{handler: handler}
+}
+
+func (h *SlogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *SlogHook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	level := slogLevel(entry.Level)
+	if !h.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	// 调用位置通过 PC 传递, handler 开启 AddSource 时输出.
+	// Frame.PC 指向调用指令, slog 解析时会减一, 这里还原为返回地址
+	var pc uintptr
+	if frame := callerFrame(entry); frame != nil {
+		pc = frame.PC + 1
+	}
+	r := slog.NewRecord(entry.Time, level, entry.Message, pc)
+	for _, key := range sortedKeys(entry.Data) {
+		r.AddAttrs(slog.Any(key, fieldValue(entry.Data[key])))
+	}
+	return h.handler.Handle(ctx, r)
+}
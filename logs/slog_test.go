@@ -0,0 +1,72 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestSlogHandler(t *testing.T) {
+	logger, buf := newTestLogger(WithFormat(FormatJSON), WithLevel(logrus.InfoLevel))
+	log := slog.New(NewSlogHandler(logger)).With("app", "demo").WithGroup("req")
+
+	log.Debug("dropped")
+	want := fmt.Sprintf("logs/slog_test.go:%d", line()+1)
+	log.Warn("slow", "method", "GET", slog.Group("user", "id", 7))
+
+	var data map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid json %q: %v", buf, err)
+	}
+	if data["level"] != "warning" || data["msg"] != "slow" || data["app"] != "demo" {
+		t.Errorf("unexpected entry: %v", data)
+	}
+	if data["req.method"] != "GET" || data["req.user.id"] != float64(7) {
+		t.Errorf("unexpected group fields: %v", data)
+	}
+	if data["caller"] != want {
+		t.Errorf("caller = %v, want %s", data["caller"], want)
+	}
+}
+
+func TestSlogHandlerText(t *testing.T) {
+	logger, buf := newTestLogger()
+	slog.New(NewSlogHandler(logger)).Info("hello", "user", "alice")
+
+	if out := buf.String(); !strings.Contains(out, "| info | logs/slog_test.go:") || !strings.Contains(out, "hello | user=alice") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestSlogHook(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true})
+	logger, _ := newTestLogger(WithHooks(NewSlogHook(handler)), WithLevel(logrus.TraceLevel))
+
+	want := line() + 1
+	logger.WithField("user", "alice").Error("boom")
+
+	var data struct {
+		Level  string `json:"level"`
+		Msg    string `json:"msg"`
+		User   string `json:"user"`
+		Source struct {
+			File string `json:"file"`
+			Line int    `json:"line"`
+		} `json:"source"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		t.Fatalf("invalid json %q: %v", buf, err)
+	}
+	if data.Level != "ERROR" || data.Msg != "boom" || data.User != "alice" {
+		t.Errorf("unexpected record: %+v", data)
+	}
+	if !strings.HasSuffix(data.Source.File, "slog_test.go") || data.Source.Line != want {
+		t.Errorf("unexpected source: %+v, want line %d", data.Source, want)
+	}
+}
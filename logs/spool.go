@@ -0,0 +1,201 @@
+package logs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrSpoolFull spool 中的日志条数达到上限
+var ErrSpoolFull = errors.New("logs: spool is full")
+
+// SpoolSink 发送失败的日志先写入本地文件, 后台定时重试, 进程重启后继续发送.
+// 文件每行一条 Record 的 JSON
+type SpoolSink struct {
+	sink     Sink
+	path     string
+	interval time.Duration
+	// MaxRecords 文件中最多保存的条数, 超出后丢弃新的日志, 默认 10000
+	MaxRecords int
+
+	mu      sync.Mutex
+	pending int
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewSpoolSink 包装 sink, 每隔 interval 重试 path 中保存的日志, interval 不大于 0 时为 30s
+func NewSpoolSink(path string, sink Sink, interval time.Duration) (*SpoolSink, error) {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	s := &SpoolSink{
+		sink:       sink,
+		path:       path,
+		interval:   interval,
+		MaxRecords: 10000,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	records, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.pending = len(records)
+	go s.run()
+	return s, nil
+}
+
+// Send 已有未发送的日志时直接写入文件, 保证发送顺序
+func (s *SpoolSink) Send(ctx context.Context, r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == 0 {
+		if err := s.sink.Send(ctx, r); err == nil {
+			return nil
+		}
+	}
+	return s.append(r)
+}
+
+// Pending 文件中等待发送的条数
+func (s *SpoolSink) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending
+}
+
+// Retry 按顺序重新发送文件中的日志, 遇到失败时停止, 剩余的写回文件
+func (s *SpoolSink) Retry(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == 0 {
+		return nil
+	}
+	records, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	sent := 0
+	for _, r := range records {
+		if err = s.sink.Send(ctx, r); err != nil {
+			break
+		}
+		sent++
+	}
+	if sent > 0 {
+		if werr := s.rewrite(records[sent:]); werr != nil {
+			return werr
+		}
+	}
+	return err
+}
+
+// Close 停止重试, 内部 sink 实现了 io.Closer 时一并关闭
+func (s *SpoolSink) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	if c, ok := s.sink.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (s *SpoolSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+			s.Retry(ctx)
+			cancel()
+		}
+	}
+}
+
+// append 追加一条到文件, 调用时需持有锁
+func (s *SpoolSink) append(r Record) error {
+	if s.MaxRecords > 0 && s.pending >= s.MaxRecords {
+		return ErrSpoolFull
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.pending++
+	return nil
+}
+
+// load 读取文件中的所有日志, 跳过无法解析的行
+func (s *SpoolSink) load() ([]Record, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var r Record
+		if json.Unmarshal(scanner.Bytes(), &r) == nil {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// rewrite 通过临时文件替换, 避免中途失败时丢失日志
+func (s *SpoolSink) rewrite(records []Record) error {
+	if len(records) == 0 {
+		s.pending = 0
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("logs: encode spool record: %w", err)
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.pending = len(records)
+	return nil
+}
@@ -0,0 +1,137 @@
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 本地 syslog 常见的 socket 路径
+var syslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// FacilityUser syslog 的 user facility
+const FacilityUser = 1
+
+// SyslogSink 以 RFC 3164 格式发送到 syslog, 连接断开后自动重连
+type SyslogSink struct {
+	network string
+	addr    string
+	tag     string
+	// Facility 默认 FacilityUser
+	Facility int
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewSyslogSink 创建 syslog 发送端, network 和 addr 为空时连接本地 socket,
+// 例如 NewSyslogSink("unixgram", "/dev/log", "app") 或 NewSyslogSink("udp", "10.0.0.1:514", "app")
+func NewSyslogSink(network, addr, tag string) *SyslogSink {
+	if tag == "" {
+		tag = os.Args[0]
+		if i := strings.LastIndexAny(tag, `/\`); i >= 0 {
+			tag = tag[i+1:]
+		}
+	}
+	return &SyslogSink{network: network, addr: addr, tag: tag, Facility: FacilityUser}
+}
+
+func (s *SyslogSink) Send(ctx context.Context, r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := s.format(r)
+	// 写入失败时重连一次
+	for i := 0; i < 2; i++ {
+		if s.conn == nil {
+			conn, err := s.dial(ctx)
+			if err != nil {
+				return err
+			}
+			s.conn = conn
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			s.conn.SetWriteDeadline(deadline)
+		}
+		if _, err := s.conn.Write(msg); err == nil {
+			return nil
+		} else if i == 1 {
+			return err
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return nil
+}
+
+// format <PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG, 本地 socket 不带主机名
+func (s *SyslogSink) format(r Record) []byte {
+	level, _ := logrus.ParseLevel(r.Level)
+	pri := s.Facility*8 + syslogSeverity(level)
+	host := ""
+	if s.network != "" && !strings.HasPrefix(s.network, "unix") {
+		name, _ := os.Hostname()
+		host = name + " "
+	}
+	msg := strings.ReplaceAll(r.Text(), "\n", " ")
+	line := fmt.Sprintf("<%d>%s %s%s[%d]: %s", pri, r.Time.Format(time.Stamp), host, s.tag, os.Getpid(), msg)
+	if s.network == "tcp" || s.network == "tcp4" || s.network == "tcp6" {
+		line += "\n"
+	}
+	return []byte(line)
+}
+
+func (s *SyslogSink) dial(ctx context.Context) (net.Conn, error) {
+	var d net.Dialer
+	if s.network != "" {
+		return d.DialContext(ctx, s.network, s.addr)
+	}
+	paths := syslogPaths
+	if s.addr != "" {
+		paths = []string{s.addr}
+	}
+	for _, path := range paths {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := d.DialContext(ctx, network, path); err == nil {
+				return conn, nil
+			}
+		}
+	}
+	return nil, errors.New("logs: unix syslog delivery error")
+}
+
+// Close 关闭连接
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// syslogSeverity logrus 级别转换为 syslog severity
+func syslogSeverity(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel:
+		return 0 // emerg
+	case logrus.FatalLevel:
+		return 2 // crit
+	case logrus.ErrorLevel:
+		return 3 // err
+	case logrus.WarnLevel:
+		return 4 // warning
+	case logrus.InfoLevel:
+		return 6 // info
+	}
+	return 7 // debug
+}
@@ -0,0 +1,99 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WebhookStyle webhook 的请求体格式
+type WebhookStyle int
+
+const (
+	// WebhookJSON 直接发送 Record 的 JSON
+	WebhookJSON WebhookStyle = iota
+	// WebhookSlack Slack Incoming Webhook
+	WebhookSlack
+	// WebhookDingTalk 钉钉自定义机器人
+	WebhookDingTalk
+	// WebhookFeishu 飞书自定义机器人
+	WebhookFeishu
+)
+
+// WebhookSink 以 HTTP POST 发送日志
+type WebhookSink struct {
+	URL    string
+	Style  WebhookStyle
+	Client *http.Client
+	// Header 额外的请求头, 例如鉴权
+	Header http.Header
+}
+
+// NewWebhookSink 创建 webhook 发送端, 与 NewSinkHook 一起使用
+func NewWebhookSink(url string, style WebhookStyle) *WebhookSink {
+	return &WebhookSink{URL: url, Style: style, Client: http.DefaultClient, Header: make(http.Header)}
+}
+
+// Payload 按 Style 生成请求体
+func (s *WebhookSink) Payload(r Record) any {
+	text := fmt.Sprintf("[%s] %s %s", strings.ToUpper(r.Level), r.Time.Format("2006-01-02 15:04:05"), r.Text())
+	switch s.Style {
+	case WebhookSlack:
+		return map[string]any{"text": text}
+	case WebhookDingTalk:
+		return map[string]any{"msgtype": "text", "text": map[string]string{"content": text}}
+	case WebhookFeishu:
+		return map[string]any{"msg_type": "text", "content": map[string]string{"text": text}}
+	}
+	return r
+}
+
+func (s *WebhookSink) Send(ctx context.Context, r Record) error {
+	body, err := json.Marshal(s.Payload(r))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range s.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("logs: webhook status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+
+	// 钉钉和飞书失败时仍返回 200, 错误码在响应体中
+	var result struct {
+		ErrCode *int   `json:"errcode"`
+		Code    *int   `json:"code"`
+		ErrMsg  string `json:"errmsg"`
+		Msg     string `json:"msg"`
+	}
+	if json.Unmarshal(data, &result) == nil {
+		if result.ErrCode != nil && *result.ErrCode != 0 {
+			return fmt.Errorf("logs: webhook error %d: %s", *result.ErrCode, result.ErrMsg)
+		}
+		if result.Code != nil && *result.Code != 0 {
+			return fmt.Errorf("logs: webhook error %d: %s", *result.Code, result.Msg)
+		}
+	}
+	return nil
+}
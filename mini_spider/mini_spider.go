@@ -9,7 +9,7 @@ import (
 	"github.com/gocolly/colly"
 )
 
-var log = logs.NewDefaultLog()
+var log = logs.NewErrorLog("mini_spider")
 
 type BaseSpider struct {
 	Name      string
@@ -21,10 +21,8 @@ type BaseSpider struct {
 
 func (sp *BaseSpider) GetIndexPage() {
 	sp.Collector.OnRequest(func(r *colly.Request) {
-		log.InfoMsg("Spider", sp.Name, "is Running!")
-		log.InfoMsg(
-			fmt.Sprintf("Visited url => %s", r.URL),
-		)
+		log.Infof("Spider %s is Running!", sp.Name)
+		log.Infow("Visited url", "spider", sp.Name, "url", r.URL.String())
 	})
 }
 
@@ -37,7 +35,7 @@ func (sp *BaseSpider) ParserHtml(tmp chan map[string]string) {
 
 			tmp <- result
 
-			log.InfoMsg(h.Text)
+			log.Debugw(h.Text, "href", h.Attr("href"))
 			h.Request.Visit(h.Attr("href"))
 		}
 	})
@@ -0,0 +1,140 @@
+// Package api reply 的响应格式和错误码, 只依赖标准库, 供服务端 reply 和客户端 knet/v2 共用
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ErrorCode 业务错误码定义, 本身实现了 error, 可以直接返回或与 errors.Is 比较
+type ErrorCode struct {
+	// Code 业务码, 全局唯一
+	Code int
+	// Status HTTP 状态码
+	Status int
+	// Key 消息 key, 用于多语言
+	Key string
+	// Message 默认消息
+	Message string
+	// Retryable 客户端是否可以重试
+	Retryable bool
+}
+
+var (
+	codesMu sync.RWMutex
+	codes   = make(map[int]*ErrorCode)
+)
+
+// Register 注册错误码, 业务码重复时 panic, 应在 init 或包级变量中调用
+func Register(code, status int, key, message string, retryable bool) *ErrorCode {
+	codesMu.Lock()
+	defer codesMu.Unlock()
+	if _, ok := codes[code]; ok {
+		panic(fmt.Sprintf("api: error code %d registered twice", code))
+	}
+	c := &ErrorCode{Code: code, Status: status, Key: key, Message: message, Retryable: retryable}
+	codes[code] = c
+	return c
+}
+
+// Lookup 根据业务码查找
+func Lookup(code int) (*ErrorCode, bool) {
+	codesMu.RLock()
+	defer codesMu.RUnlock()
+	c, ok := codes[code]
+	return c, ok
+}
+
+// Codes 所有已注册的错误码, 按业务码排序, 可用于生成文档
+func Codes() []*ErrorCode {
+	codesMu.RLock()
+	defer codesMu.RUnlock()
+	list := make([]*ErrorCode, 0, len(codes))
+	for _, c := range codes {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Code < list[j].Code })
+	return list
+}
+
+// 通用错误码, 业务码为 HTTP 状态码 * 100 + 序号
+var (
+	CodeBadRequest      = Register(40000, http.StatusBadRequest, "bad_request", "请求参数错误", false)
+	CodeValidation      = Register(40001, http.StatusBadRequest, "validation_failed", "参数校验失败", false)
+	CodeUnauthorized    = Register(40100, http.StatusUnauthorized, "unauthorized", "未登录或登录已过期", false)
+	CodeForbidden       = Register(40300, http.StatusForbidden, "forbidden", "没有权限", false)
+	CodeNotFound        = Register(40400, http.StatusNotFound, "not_found", "资源不存在", false)
+	CodeConflict        = Register(40900, http.StatusConflict, "conflict", "资源冲突", false)
+	CodeTooManyRequests = Register(42900, http.StatusTooManyRequests, "too_many_requests", "请求过于频繁", true)
+	CodeInternal        = Register(50000, http.StatusInternalServerError, "internal_error", "服务器内部错误", false)
+	CodeUnavailable     = Register(50300, http.StatusServiceUnavailable, "service_unavailable", "服务暂不可用", true)
+	CodeTimeout         = Register(50400, http.StatusGatewayTimeout, "timeout", "请求超时", true)
+)
+
+func (c *ErrorCode) Error() string {
+	return c.Message
+}
+
+// New 创建错误, msg 为空时使用默认消息
+func (c *ErrorCode) New(msg string) *AppError {
+	return &AppError{Code: c, Msg: msg}
+}
+
+// Errorf 创建带格式化消息的错误
+func (c *ErrorCode) Errorf(format string, args ...any) *AppError {
+	return &AppError{Code: c, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Wrap 包装底层错误, 5xx 错误的 cause 不会返回给客户端
+func (c *ErrorCode) Wrap(cause error) *AppError {
+	return &AppError{Code: c, Cause: cause}
+}
+
+// AppError 业务错误
+type AppError struct {
+	Code *ErrorCode
+	// Msg 覆盖默认消息
+	Msg string
+	// Cause 底层错误
+	Cause error
+	// Details 附加数据, 例如字段校验错误
+	Details any
+}
+
+// WithDetails 设置附加数据
+func (e *AppError) WithDetails(details any) *AppError {
+	e.Details = details
+	return e
+}
+
+// Message 返回给客户端的消息
+func (e *AppError) Message() string {
+	if e.Msg != "" {
+		return e.Msg
+	}
+	return e.Code.Message
+}
+
+func (e *AppError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%d %s: %v", e.Code.Code, e.Message(), e.Cause)
+	}
+	return fmt.Sprintf("%d %s", e.Code.Code, e.Message())
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Cause
+}
+
+// Is 与同一业务码的 ErrorCode 或 AppError 相等
+func (e *AppError) Is(target error) bool {
+	switch t := target.(type) {
+	case *ErrorCode:
+		return t.Code == e.Code.Code
+	case *AppError:
+		return t.Code.Code == e.Code.Code
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// SuccessCode 成功时的业务码, 与 reply.Succeed 一致
+const SuccessCode = http.StatusOK
+
+// Response 泛型返回格式, JSON 结构与 reply.JsonMsg 相同
+type Response[T any] struct {
+	Code int     `json:"code"`
+	Msg  string  `json:"msg"`
+	Err  *string `json:"err,omitempty"`
+	Data T       `json:"data,omitempty"`
+}
+
+// Success 业务码为 0 或 2xx 时视为成功
+func (r *Response[T]) Success() bool {
+	return r.Code == 0 || (r.Code >= 200 && r.Code < 300)
+}
+
+// Envelope 返回各字段, 供 reply 按 XML、Protobuf 等格式输出
+func (r Response[T]) Envelope() (code int, msg string, err *string, data any) {
+	return r.Code, r.Msg, r.Err, r.Data
+}
+
+// Decode 解析 gouitls 格式的响应, 业务码表示失败时返回 *AppError,
+// 已注册的业务码可以用 errors.Is(err, CodeNotFound) 判断
+func Decode[T any](status int, body []byte) (*Response[T], error) {
+	var resp Response[T]
+	if err := json.Unmarshal(body, &resp); err != nil {
+		if status < 200 || status >= 300 {
+			return nil, responseError(status, status, http.StatusText(status), "")
+		}
+		return nil, fmt.Errorf("api: decode response: %w", err)
+	}
+	if !resp.Success() {
+		cause := ""
+		if resp.Err != nil {
+			cause = *resp.Err
+		}
+		return &resp, responseError(status, resp.Code, resp.Msg, cause)
+	}
+	return &resp, nil
+}
+
+// responseError 根据业务码创建错误, 未注册的业务码使用响应中的状态码
+func responseError(status, code int, msg, cause string) *AppError {
+	c, ok := Lookup(code)
+	if !ok {
+		c = &ErrorCode{Code: code, Status: status, Message: msg}
+	}
+	err := &AppError{Code: c, Msg: msg}
+	if cause != "" {
+		err.Cause = errors.New(cause)
+	}
+	return err
+}
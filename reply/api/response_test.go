@@ -0,0 +1,30 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	resp, err := Decode[map[string]string](http.StatusOK, []byte(`{"code":200,"msg":"success","data":{"name":"alice"}}`))
+	if err != nil || !resp.Success() || resp.Data["name"] != "alice" {
+		t.Errorf("unexpected response %+v, %v", resp, err)
+	}
+
+	var app *AppError
+	_, err = Decode[any](http.StatusNotFound, []byte(`{"code":40400,"msg":"用户不存在"}`))
+	if !errors.Is(err, CodeNotFound) || !errors.As(err, &app) || app.Message() != "用户不存在" {
+		t.Errorf("unexpected error %v", err)
+	}
+	_, err = Decode[any](http.StatusBadRequest, []byte(`{"code":400,"msg":"登录失败","err":"bad password"}`))
+	if !errors.As(err, &app) || app.Code.Code != 400 || app.Cause == nil || app.Cause.Error() != "bad password" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := Decode[any](http.StatusBadGateway, []byte("<html>")); !errors.As(err, &app) || app.Code.Status != http.StatusBadGateway {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := Decode[any](http.StatusOK, []byte("<html>")); err == nil || errors.As(err, &app) {
+		t.Errorf("expected decode error, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package reply
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entrans "github.com/go-playground/validator/v10/translations/en"
+	zhtrans "github.com/go-playground/validator/v10/translations/zh"
+	"golang.org/x/text/language"
+)
+
+// 支持的语言
+const (
+	LangZh = "zh"
+	LangEn = "en"
+)
+
+// DefaultLang 没有 Accept-Language 或无法匹配时使用的语言
+var DefaultLang = LangZh
+
+var (
+	langMatcher = language.NewMatcher([]language.Tag{language.Chinese, language.English})
+	langNames   = []string{LangZh, LangEn}
+)
+
+// Lang 根据用户设置的语言或 Accept-Language 选择校验提示的语言
+func Lang(ctx *gin.Context) string {
+	var tags []language.Tag
+	for _, pref := range Languages(ctx) {
+		if t, _, err := language.ParseAcceptLanguage(pref); err == nil {
+			tags = append(tags, t...)
+		}
+	}
+	if len(tags) == 0 {
+		return DefaultLang
+	}
+	_, index, confidence := langMatcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLang
+	}
+	return langNames[index]
+}
+
+// validate Bind 使用的校验器, 与 gin 一样读取 binding 标签, 字段名使用 json/form/uri 标签.
+// 使用独立的实例, 不修改 gin 的全局校验器
+var validate, translators = newValidator()
+
+func newValidator() (*validator.Validate, map[string]ut.Translator) {
+	v := validator.New()
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{"json", "form", "uri"} {
+			name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+			if name == "-" {
+				break
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return f.Name
+	})
+
+	translators := make(map[string]ut.Translator)
+	uni := ut.New(zh.New(), zh.New(), en.New())
+	zhT, _ := uni.GetTranslator(LangZh)
+	enT, _ := uni.GetTranslator(LangEn)
+	if zhtrans.RegisterDefaultTranslations(v, zhT) == nil {
+		translators[LangZh] = zhT
+	}
+	if entrans.RegisterDefaultTranslations(v, enT) == nil {
+		translators[LangEn] = enT
+	}
+	return v, translators
+}
+
+// validateValue 校验结构体, 指针、切片和数组逐个校验其中的结构体
+func validateValue(obj any) error {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return validate.Struct(v.Interface())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(v.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// localizeFields 将校验错误翻译为指定语言, 只有 Bind 产生的校验错误有翻译,
+// 其他校验器的错误保留原始信息
+func localizeFields(errs validator.ValidationErrors, lang string) []FieldError {
+	fields := fieldErrors(errs)
+	if trans, ok := translators[lang]; ok {
+		for i, fe := range errs {
+			fields[i].Message = fe.Translate(trans)
+		}
+	}
+	return fields
+}
+
+// ShouldBind 依次绑定路径参数(uri 标签)、查询参数(form 标签)和请求体,
+// 请求体按 Content-Type 解析 JSON、XML 或表单, 最后统一校验(binding 标签)
+func ShouldBind[T any](ctx *gin.Context) (T, error) {
+	var v T
+
+	if len(ctx.Params) > 0 {
+		params := make(map[string][]string, len(ctx.Params))
+		for _, p := range ctx.Params {
+			params[p.Key] = []string{p.Value}
+		}
+		if err := binding.MapFormWithTag(&v, params, "uri"); err != nil {
+			return v, CodeBadRequest.Wrap(err)
+		}
+	}
+	if query := ctx.Request.URL.Query(); len(query) > 0 {
+		if err := binding.MapFormWithTag(&v, query, "form"); err != nil {
+			return v, CodeBadRequest.Wrap(err)
+		}
+	}
+	if err := bindBody(ctx, &v); err != nil {
+		return v, err
+	}
+
+	if err := validateValue(&v); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+// Bind 同 ShouldBind, 失败时通过 Error 返回错误并终止请求,
+// 校验错误按 Accept-Language 翻译为每个字段的提示
+//
+//	req, ok := reply.Bind[CreateUser](ctx)
+//	if !ok {
+//		return
+//	}
+func Bind[T any](ctx *gin.Context) (T, bool) {
+	v, err := ShouldBind[T](ctx)
+	if err != nil {
+		Error(ctx, err)
+		return v, false
+	}
+	return v, true
+}
+
+func bindBody(ctx *gin.Context, v any) error {
+	req := ctx.Request
+	if req.Body == nil || req.ContentLength == 0 || req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return nil
+	}
+
+	var err error
+	switch ctx.ContentType() {
+	case binding.MIMEJSON:
+		dec := json.NewDecoder(req.Body)
+		if binding.EnableDecoderUseNumber {
+			dec.UseNumber()
+		}
+		if binding.EnableDecoderDisallowUnknownFields {
+			dec.DisallowUnknownFields()
+		}
+		err = dec.Decode(v)
+	case binding.MIMEXML, binding.MIMEXML2:
+		err = xml.NewDecoder(req.Body).Decode(v)
+	case binding.MIMEPOSTForm:
+		if err = req.ParseForm(); err == nil {
+			err = binding.MapFormWithTag(v, req.PostForm, "form")
+		}
+	case binding.MIMEMultipartPOSTForm:
+		if err = req.ParseMultipartForm(32 << 20); err == nil {
+			err = binding.MapFormWithTag(v, req.MultipartForm.Value, "form")
+		}
+	default:
+		return CodeBadRequest.Errorf("不支持的 Content-Type: %s", ctx.ContentType())
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return CodeBadRequest.Wrap(err)
+	}
+	return nil
+}
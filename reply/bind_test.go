@@ -0,0 +1,119 @@
+package reply
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+type createUser struct {
+	Org   string `uri:"org" binding:"required"`
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+	Age   int    `json:"age" form:"age" binding:"gte=0,lte=150"`
+	Dry   bool   `form:"dry"`
+}
+
+func doBind(method, target, contentType, body, lang string) (*httptest.ResponseRecorder, createUser, bool) {
+	gin.SetMode(gin.TestMode)
+	var (
+		req createUser
+		ok  bool
+	)
+	r := gin.New()
+	r.Handle(method, "/orgs/:org/users", func(ctx *gin.Context) {
+		req, ok = Bind[createUser](ctx)
+		if ok {
+			OK(ctx, req)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	hr := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		hr.Header.Set("Content-Type", contentType)
+	}
+	if lang != "" {
+		hr.Header.Set("Accept-Language", lang)
+	}
+	r.ServeHTTP(w, hr)
+	return w, req, ok
+}
+
+func TestBind(t *testing.T) {
+	w, req, ok := doBind(http.MethodPost, "/orgs/acme/users?dry=true", binding.MIMEJSON, `{"name":"alice","email":"alice@example.com","age":30}`, "")
+	if !ok || w.Code != http.StatusOK {
+		t.Fatalf("bind failed: %d %s", w.Code, w.Body)
+	}
+	if req != (createUser{Org: "acme", Name: "alice", Email: "alice@example.com", Age: 30, Dry: true}) {
+		t.Errorf("unexpected value %+v", req)
+	}
+
+	_, req, ok = doBind(http.MethodPost, "/orgs/acme/users", "application/x-www-form-urlencoded", "age=20", "")
+	if ok || req.Age != 20 {
+		t.Errorf("form: got %+v %v", req, ok)
+	}
+
+	w, _, ok = doBind(http.MethodPost, "/orgs/acme/users", binding.MIMEJSON, `{"name":`, "")
+	if ok || w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "40000") {
+		t.Errorf("malformed json: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestBindValidation(t *testing.T) {
+	cases := map[string]string{
+		"":                "email必须是一个有效的邮箱",
+		"zh-CN,zh;q=0.9":  "email必须是一个有效的邮箱",
+		"en-US,en;q=0.8":  "email must be a valid email address",
+		"fr-FR, en;q=0.5": "email must be a valid email address",
+		"ja-JP":           "email必须是一个有效的邮箱",
+	}
+	for lang, want := range cases {
+		w, _, ok := doBind(http.MethodPost, "/orgs/acme/users", binding.MIMEJSON, `{"name":"alice","email":"bad"}`, lang)
+		if ok || w.Code != http.StatusBadRequest {
+			t.Fatalf("%q: expected 400, got %d", lang, w.Code)
+		}
+		var body struct {
+			Code int          `json:"code"`
+			Err  string       `json:"err"`
+			Data []FieldError `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if body.Code != CodeValidation.Code || len(body.Data) != 1 {
+			t.Fatalf("%q: unexpected body %s", lang, w.Body)
+		}
+		fe := body.Data[0]
+		if fe.Field != "email" || fe.Rule != "email" || fe.Message != want || body.Err != want {
+			t.Errorf("%q: got %+v, err %q", lang, fe, body.Err)
+		}
+	}
+}
+
+func TestLang(t *testing.T) {
+	ctx, _ := testContext("/")
+	if Lang(ctx) != LangZh {
+		t.Error("default language should be zh")
+	}
+	ctx.Request.Header.Set("Accept-Language", "en-GB;q=0.9, zh-TW;q=0.5")
+	if Lang(ctx) != LangEn {
+		t.Error("expected en")
+	}
+}
+
+func TestBindKeepsGinValidator(t *testing.T) {
+	doBind(http.MethodPost, "/orgs/acme/users", binding.MIMEJSON, `{}`, "")
+
+	// Bind 不修改 gin 的全局校验器, ctx.ShouldBind 的字段名保持不变
+	err := binding.Validator.ValidateStruct(&createUser{Org: "acme", Name: "alice"})
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) || errs[0].Field() != "Email" {
+		t.Errorf("unexpected gin validation error %v", err)
+	}
+}
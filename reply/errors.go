@@ -0,0 +1,155 @@
+package reply
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Fromsko/gouitls/reply/api"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// ErrorCode 业务错误码定义, 见 api.ErrorCode
+type ErrorCode = api.ErrorCode
+
+// AppError 业务错误, 见 api.AppError
+type AppError = api.AppError
+
+// Register 注册错误码, 业务码重复时 panic, 应在 init 或包级变量中调用
+func Register(code, status int, key, message string, retryable bool) *ErrorCode {
+	return api.Register(code, status, key, message, retryable)
+}
+
+// Lookup 根据业务码查找
+func Lookup(code int) (*ErrorCode, bool) {
+	return api.Lookup(code)
+}
+
+// Codes 所有已注册的错误码, 按业务码排序, 可用于生成文档
+func Codes() []*ErrorCode {
+	return api.Codes()
+}
+
+// 通用错误码, 定义在 api 中, 客户端不需要依赖 reply 即可识别
+var (
+	CodeBadRequest      = api.CodeBadRequest
+	CodeValidation      = api.CodeValidation
+	CodeUnauthorized    = api.CodeUnauthorized
+	CodeForbidden       = api.CodeForbidden
+	CodeNotFound        = api.CodeNotFound
+	CodeConflict        = api.CodeConflict
+	CodeTooManyRequests = api.CodeTooManyRequests
+	CodeInternal        = api.CodeInternal
+	CodeUnavailable     = api.CodeUnavailable
+	CodeTimeout         = api.CodeTimeout
+)
+
+// Mapper 将特定错误转换为 AppError, 不处理时返回 nil
+type Mapper func(err error) *AppError
+
+var (
+	mappersMu sync.RWMutex
+	mappers   []Mapper
+)
+
+// RegisterMapper 注册错误转换, 后注册的优先, 用于接入其他库的错误类型
+func RegisterMapper(m Mapper) {
+	mappersMu.Lock()
+	defer mappersMu.Unlock()
+	mappers = append([]Mapper{m}, mappers...)
+}
+
+// FieldError 字段校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// AsAppError 将任意错误转换为 AppError, 无法识别的错误视为 CodeInternal
+func AsAppError(err error) *AppError {
+	if err == nil {
+		return nil
+	}
+
+	var app *AppError
+	if errors.As(err, &app) {
+		return app
+	}
+	var code *ErrorCode
+	if errors.As(err, &code) {
+		if err == error(code) {
+			return &AppError{Code: code}
+		}
+		return &AppError{Code: code, Cause: err}
+	}
+
+	mappersMu.RLock()
+	for _, m := range mappers {
+		if app := m(err); app != nil {
+			mappersMu.RUnlock()
+			return app
+		}
+	}
+	mappersMu.RUnlock()
+
+	var (
+		validationErrs validator.ValidationErrors
+		syntaxErr      *json.SyntaxError
+		typeErr        *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return CodeNotFound.Wrap(err)
+	case errors.As(err, &validationErrs):
+		return CodeValidation.Wrap(err).WithDetails(fieldErrors(validationErrs))
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return CodeBadRequest.Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout.Wrap(err)
+	}
+	return CodeInternal.Wrap(err)
+}
+
+func fieldErrors(errs validator.ValidationErrors) []FieldError {
+	fields := make([]FieldError, len(errs))
+	for i, fe := range errs {
+		fields[i] = FieldError{Field: fe.Field(), Rule: fe.Tag(), Param: fe.Param(), Message: fe.Error()}
+	}
+	return fields
+}
+
+// Error 将错误转换为对应的 HTTP 状态码和 JsonMsg 并终止后续处理.
+// 4xx 错误在 err 字段中返回原因, 5xx 不返回, 原始错误记录到 ctx.Errors 供访问日志使用.
+// err 为 nil 时不做任何处理
+func Error(ctx *gin.Context, err error) {
+	if err == nil {
+		return
+	}
+	app := AsAppError(err)
+	ctx.Error(err)
+
+	jm := &JsonMsg{Code: app.Code.Code, Msg: errorMessage(ctx, app), Data: app.Details}
+	if app.Cause != nil && app.Code.Status < http.StatusInternalServerError {
+		cause := app.Cause.Error()
+		var validationErrs validator.ValidationErrors
+		if _, ok := app.Details.([]FieldError); ok && errors.As(app.Cause, &validationErrs) {
+			fields := localizeFields(validationErrs, Lang(ctx))
+			messages := make([]string, len(fields))
+			for i, f := range fields {
+				messages[i] = f.Message
+			}
+			cause = strings.Join(messages, "; ")
+			jm.Data = fields
+		}
+		jm.Err = &cause
+	}
+	ctx.Abort()
+	Render(ctx, app.Code.Status, jm)
+}
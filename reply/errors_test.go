@@ -0,0 +1,97 @@
+package reply
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+func doError(err error) (*httptest.ResponseRecorder, map[string]any) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	Error(ctx, err)
+
+	var body map[string]any
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return w, body
+}
+
+func TestError(t *testing.T) {
+	type user struct {
+		Name string `validate:"required"`
+	}
+	validationErr := validator.New().Struct(user{})
+
+	cases := []struct {
+		err    error
+		status int
+		code   float64
+		msg    string
+		hasErr bool
+	}{
+		{fmt.Errorf("find user: %w", gorm.ErrRecordNotFound), 404, 40400, "资源不存在", true},
+		{validationErr, 400, 40001, "参数校验失败", true},
+		{CodeForbidden, 403, 40300, "没有权限", false},
+		{CodeConflict.Errorf("用户 %s 已存在", "alice"), 409, 40900, "用户 alice 已存在", false},
+		{fmt.Errorf("query: %w", CodeUnavailable.Wrap(errors.New("redis down"))), 503, 50300, "服务暂不可用", false},
+		{errors.New("secret internal detail"), 500, 50000, "服务器内部错误", false},
+	}
+	for _, tc := range cases {
+		w, body := doError(tc.err)
+		if w.Code != tc.status || body["code"] != tc.code || body["msg"] != tc.msg {
+			t.Errorf("%v: got %d %v", tc.err, w.Code, body)
+		}
+		if _, ok := body["err"]; ok != tc.hasErr {
+			t.Errorf("%v: unexpected err field %v", tc.err, body)
+		}
+	}
+
+	// nil 错误不写入响应
+	if w, _ := doError(nil); w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+		t.Errorf("nil error: got %d %q", w.Code, w.Body)
+	}
+
+	_, body := doError(validationErr)
+	fields, _ := body["data"].([]any)
+	if len(fields) != 1 || fields[0].(map[string]any)["field"] != "Name" || fields[0].(map[string]any)["rule"] != "required" {
+		t.Errorf("unexpected field errors %v", body["data"])
+	}
+}
+
+func TestAppError(t *testing.T) {
+	cause := errors.New("disk full")
+	err := fmt.Errorf("save: %w", CodeInternal.Wrap(cause))
+	if !errors.Is(err, CodeInternal) || errors.Is(err, CodeNotFound) || !errors.Is(err, cause) {
+		t.Error("unexpected errors.Is result")
+	}
+	if c, ok := Lookup(42900); !ok || !c.Retryable || c.Status != http.StatusTooManyRequests {
+		t.Errorf("unexpected lookup %+v", c)
+	}
+
+	teapot := errors.New("teapot")
+	RegisterMapper(func(err error) *AppError {
+		if errors.Is(err, teapot) {
+			return CodeBadRequest.Wrap(err)
+		}
+		return nil
+	})
+	if w, _ := doError(teapot); w.Code != http.StatusBadRequest {
+		t.Errorf("mapper not applied, got %d", w.Code)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate code should panic")
+		}
+	}()
+	Register(40000, 400, "dup", "dup", false)
+}
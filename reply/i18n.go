@@ -0,0 +1,61 @@
+package reply
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/Fromsko/gouitls/i18n"
+)
+
+// ContextLangKey gin.Context 中用户设置的语言, 优先于 Accept-Language
+//
+//	ctx.Set(reply.ContextLangKey, user.Lang)
+const ContextLangKey = "lang"
+
+// Messages reply 使用的消息目录, 默认为 i18n.Default
+var Messages = i18n.Default
+
+// Languages 请求的语言偏好, 用户设置在前, 然后是 Accept-Language
+func Languages(ctx *gin.Context) []string {
+	var prefs []string
+	if lang := ctx.GetString(ContextLangKey); lang != "" {
+		prefs = append(prefs, lang)
+	}
+	if header := ctx.GetHeader("Accept-Language"); header != "" {
+		prefs = append(prefs, header)
+	}
+	return prefs
+}
+
+// Locale 从 Messages 已加载的语言中选择请求使用的语言
+func Locale(ctx *gin.Context) string {
+	return Messages.Match(Languages(ctx)...)
+}
+
+// T 按请求的语言翻译消息 key, 找不到时原样返回 key
+//
+//	reply.Succeed(ctx, reply.T(ctx, "user.created", i18n.Args{"Name": name}))
+func T(ctx *gin.Context, key string, args ...i18n.Args) string {
+	return Messages.T(Locale(ctx), key, args...)
+}
+
+// translate msg 为已加载的消息 key 时翻译, 否则原样返回,
+// 因此 Succeed、Failed、WithMsg 等既可以传 key 也可以传普通文本
+func translate(ctx *gin.Context, msg string) string {
+	if msg == "" || len(Messages.Languages()) == 0 {
+		return msg
+	}
+	if s, ok := Messages.Lookup(Locale(ctx), msg); ok {
+		return s
+	}
+	return msg
+}
+
+// errorMessage AppError 没有自定义消息时按 ErrorCode.Key 翻译
+func errorMessage(ctx *gin.Context, app *AppError) string {
+	if app.Msg == "" && app.Code.Key != "" {
+		if s, ok := Messages.Lookup(Locale(ctx), app.Code.Key); ok {
+			return s
+		}
+	}
+	return translate(ctx, app.Message())
+}
@@ -0,0 +1,73 @@
+package reply
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Fromsko/gouitls/i18n"
+)
+
+func useMessages(t *testing.T) {
+	t.Helper()
+	c := i18n.New("zh")
+	c.Add("zh", map[string]any{
+		"user":      map[string]any{"created": "用户 {{.Name}} 已创建"},
+		"not_found": "找不到资源",
+	})
+	c.Add("en", map[string]any{
+		"user":      map[string]any{"created": "User {{.Name}} created"},
+		"not_found": "Resource not found",
+		"saved":     "Saved",
+	})
+	old := Messages
+	Messages = c
+	t.Cleanup(func() { Messages = old })
+}
+
+func TestMessageKeys(t *testing.T) {
+	useMessages(t)
+
+	msg := func(w interface{ Bytes() []byte }) string {
+		var body map[string]any
+		json.Unmarshal(w.Bytes(), &body)
+		s, _ := body["msg"].(string)
+		return s
+	}
+
+	ctx, w := testContext("/")
+	ctx.Request.Header.Set("Accept-Language", "en-US")
+	Succeed(ctx, "saved")
+	if got := msg(w.Body); got != "Saved" {
+		t.Errorf("Succeed: got %q", got)
+	}
+
+	ctx, w = testContext("/")
+	Client(ctx, nil, WithCode(200), WithMsg("保存成功"))
+	if got := msg(w.Body); got != "保存成功" {
+		t.Errorf("literal message should be unchanged, got %q", got)
+	}
+
+	ctx, w = testContext("/")
+	ctx.Request.Header.Set("Accept-Language", "zh-CN")
+	ctx.Set(ContextLangKey, "en")
+	OK(ctx, gin.H{}, T(ctx, "user.created", i18n.Args{"Name": "alice"}))
+	if got := msg(w.Body); got != "User alice created" {
+		t.Errorf("user setting should win over Accept-Language, got %q", got)
+	}
+
+	ctx, w = testContext("/")
+	ctx.Request.Header.Set("Accept-Language", "en")
+	Error(ctx, CodeNotFound.Wrap(errors.New("no rows")))
+	if got := msg(w.Body); got != "Resource not found" {
+		t.Errorf("Error: got %q", got)
+	}
+
+	ctx, w = testContext("/")
+	Error(ctx, CodeConflict.New("not_found"))
+	if got := msg(w.Body); got != "找不到资源" {
+		t.Errorf("Error with key: got %q", got)
+	}
+}
@@ -0,0 +1,186 @@
+package reply
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	"google.golang.org/protobuf/proto"
+)
+
+// 响应格式, 可通过查询参数 format 指定, 优先于 Accept
+const (
+	FormatJSON     = "json"
+	FormatXML      = "xml"
+	FormatYAML     = "yaml"
+	FormatMsgPack  = "msgpack"
+	FormatProtobuf = "protobuf"
+)
+
+// QueryFormat 覆盖 Accept 的查询参数, 例如 ?format=xml
+const QueryFormat = "format"
+
+// CodeNotAcceptable 没有客户端可接受的响应格式
+var CodeNotAcceptable = Register(40600, http.StatusNotAcceptable, "not_acceptable", "不支持的响应格式", false)
+
+// formats 按优先级排列, Accept 为 */* 时使用第一个
+var formats = []struct {
+	name  string
+	mimes []string
+}{
+	{FormatJSON, []string{binding.MIMEJSON}},
+	{FormatXML, []string{binding.MIMEXML, binding.MIMEXML2}},
+	{FormatYAML, []string{binding.MIMEYAML, "application/yaml", "text/yaml"}},
+	{FormatMsgPack, []string{binding.MIMEMSGPACK, binding.MIMEMSGPACK2}},
+	{FormatProtobuf, []string{binding.MIMEPROTOBUF, "application/protobuf"}},
+}
+
+// envelopeOf 取出 JsonMsg 和 api.Response[T] 的公共结构, 用于 XML 和 Protobuf 输出
+func envelopeOf(body any) (*JsonMsg, bool) {
+	switch b := body.(type) {
+	case *JsonMsg:
+		return b, true
+	case interface {
+		Envelope() (int, string, *string, any)
+	}:
+		jm := &JsonMsg{}
+		jm.Code, jm.Msg, jm.Err, jm.Data = b.Envelope()
+		return jm, true
+	}
+	return nil, false
+}
+
+// Render 按 format 参数或 Accept 头输出 body, 没有可接受的格式时返回 406.
+// Protobuf 只在 data 为 proto.Message 时可用, 只输出 data 本身
+func Render(ctx *gin.Context, status int, body any) {
+	var data any
+	if jm, ok := envelopeOf(body); ok {
+		data = jm.Data
+	}
+	_, isProto := data.(proto.Message)
+
+	ctx.Header("Vary", "Accept")
+	name, ok := Negotiate(ctx, isProto)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusNotAcceptable, &JsonMsg{Code: CodeNotAcceptable.Code, Msg: errorMessage(ctx, &AppError{Code: CodeNotAcceptable})})
+		return
+	}
+
+	switch name {
+	case FormatXML:
+		ctx.XML(status, xmlBody(body))
+	case FormatYAML:
+		ctx.YAML(status, body)
+	case FormatMsgPack:
+		ctx.Render(status, render.MsgPack{Data: body})
+	case FormatProtobuf:
+		ctx.ProtoBuf(status, data)
+	default:
+		ctx.JSON(status, body)
+	}
+}
+
+// Negotiate 选择响应格式, 返回格式名
+func Negotiate(ctx *gin.Context, allowProto bool) (string, bool) {
+	offered := func(name string) bool {
+		return name != FormatProtobuf || allowProto
+	}
+
+	if name := strings.ToLower(ctx.Query(QueryFormat)); name != "" {
+		for _, f := range formats {
+			if f.name == name && offered(name) {
+				return name, true
+			}
+		}
+		return "", false
+	}
+
+	accept := ctx.GetHeader("Accept")
+	if accept == "" {
+		return FormatJSON, true
+	}
+	for _, media := range parseAccept(accept) {
+		for _, f := range formats {
+			if !offered(f.name) {
+				continue
+			}
+			for _, mime := range f.mimes {
+				if matchMedia(media, mime) {
+					return f.name, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// parseAccept 解析 Accept, 按 q 值从高到低排序, 忽略 q=0
+func parseAccept(header string) []string {
+	type media struct {
+		name string
+		q    float64
+	}
+	var list []media
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		m := media{name: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		for _, param := range params[1:] {
+			if k, v, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.TrimSpace(k) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					m.q = q
+				}
+			}
+		}
+		if m.name != "" && m.q > 0 {
+			list = append(list, m)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].q > list[j].q })
+
+	names := make([]string, len(list))
+	for i, m := range list {
+		names[i] = m.name
+	}
+	return names
+}
+
+// matchMedia 支持 */* 和 type/* 通配
+func matchMedia(accepted, mime string) bool {
+	if accepted == "*/*" || accepted == mime {
+		return true
+	}
+	typ, sub, _ := strings.Cut(accepted, "/")
+	return sub == "*" && strings.HasPrefix(mime, typ+"/")
+}
+
+// xmlEnvelope XML 输出的结构, 字段名与 JSON 相同
+type xmlEnvelope struct {
+	XMLName xml.Name `xml:"response"`
+	Code    int      `xml:"code"`
+	Msg     string   `xml:"msg"`
+	Err     *string  `xml:"err,omitempty"`
+	Data    any      `xml:"data,omitempty"`
+}
+
+// xmlBody encoding/xml 不支持 map[string]any, 转换为可序列化的 gin.H
+func xmlBody(body any) any {
+	if jm, ok := envelopeOf(body); ok {
+		env := xmlEnvelope{Code: jm.Code, Msg: jm.Msg, Err: jm.Err, Data: jm.Data}
+		if m, ok := env.Data.(map[string]any); ok {
+			env.Data = gin.H(m)
+		}
+		return env
+	}
+	switch b := body.(type) {
+	case gin.H:
+		return b
+	case map[string]any:
+		return gin.H(b)
+	}
+	return body
+}
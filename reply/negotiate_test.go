@@ -0,0 +1,86 @@
+package reply
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gopkg.in/yaml.v3"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		target, accept string
+		proto          bool
+		want           string
+		ok             bool
+	}{
+		{"/", "", false, FormatJSON, true},
+		{"/", "text/html, */*;q=0.1", false, FormatJSON, true},
+		{"/", "application/json;q=0.5, text/xml", false, FormatXML, true},
+		{"/", "application/msgpack", false, FormatMsgPack, true},
+		{"/", "application/*;q=0.2, application/yaml", false, FormatYAML, true},
+		{"/", "application/x-protobuf", true, FormatProtobuf, true},
+		{"/", "application/x-protobuf", false, "", false},
+		{"/", "text/html, application/json;q=0", false, "", false},
+		{"/?format=yaml", "application/json", false, FormatYAML, true},
+		{"/?format=csv", "", false, "", false},
+	}
+	for _, tc := range cases {
+		ctx, _ := testContext(tc.target)
+		ctx.Request.Header.Set("Accept", tc.accept)
+		if got, ok := Negotiate(ctx, tc.proto); got != tc.want || ok != tc.ok {
+			t.Errorf("%s %q: got %q %v", tc.target, tc.accept, got, ok)
+		}
+	}
+}
+
+func TestRenderFormats(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	ctx, w := testContext("/")
+	ctx.Request.Header.Set("Accept", "application/xml")
+	OK(ctx, user{Name: "alice"})
+	var env struct {
+		XMLName xml.Name `xml:"response"`
+		Code    int      `xml:"code"`
+		Data    user     `xml:"data"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &env); err != nil || env.Code != 200 || env.Data.Name != "alice" {
+		t.Errorf("unexpected xml %s: %v", w.Body, err)
+	}
+
+	ctx, w = testContext("/?format=yaml")
+	Client(ctx, &JsonMsg{Code: 200, Msg: "ok", Data: map[string]any{"n": 1}})
+	var out map[string]any
+	if err := yaml.Unmarshal(w.Body.Bytes(), &out); err != nil || out["msg"] != "ok" || out["data"].(map[string]any)["n"] != 1 {
+		t.Errorf("unexpected yaml %s: %v", w.Body, err)
+	}
+
+	ctx, w = testContext("/")
+	ctx.Request.Header.Set("Accept", "application/x-msgpack")
+	Succeed(ctx, "ok")
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "msgpack") || !strings.Contains(w.Body.String(), "msg") {
+		t.Errorf("unexpected msgpack %q %q", ct, w.Body)
+	}
+
+	ctx, w = testContext("/")
+	ctx.Request.Header.Set("Accept", "application/x-protobuf")
+	OK(ctx, wrapperspb.String("alice"))
+	var msg wrapperspb.StringValue
+	if err := proto.Unmarshal(w.Body.Bytes(), &msg); err != nil || msg.Value != "alice" {
+		t.Errorf("unexpected protobuf %q: %v", w.Body, err)
+	}
+
+	ctx, w = testContext("/")
+	ctx.Request.Header.Set("Accept", "text/html")
+	Fail(ctx, CodeNotFound)
+	if w.Code != http.StatusNotAcceptable || !strings.Contains(w.Body.String(), "40600") {
+		t.Errorf("expected 406, got %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,175 @@
+package reply
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 分页的查询参数
+const (
+	QueryPage   = "page"
+	QuerySize   = "size"
+	QueryCursor = "cursor"
+)
+
+// Pagination 分页参数, Cursor 非空时为游标分页, 否则为页码分页
+type Pagination struct {
+	Page   int    `json:"page,omitempty"`
+	Size   int    `json:"size"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// Offset 页码分页的偏移量
+func (p Pagination) Offset() int {
+	return max(p.Page-1, 0) * p.Size
+}
+
+// Scope gorm 分页, 用法 db.Scopes(p.Scope).Find(&users)
+func (p Pagination) Scope(db *gorm.DB) *gorm.DB {
+	return db.Offset(p.Offset()).Limit(p.Size)
+}
+
+// pageConfig 分页解析配置
+type pageConfig struct {
+	size    int
+	maxSize int
+}
+
+// PageOption 分页解析选项
+type PageOption func(*pageConfig)
+
+// WithPageSize 默认每页条数和最大条数, 默认 20 和 100, 超过最大值时按最大值处理, maxSize 为 0 表示不限制
+func WithPageSize(size, maxSize int) PageOption {
+	return func(c *pageConfig) {
+		c.size, c.maxSize = size, maxSize
+	}
+}
+
+// ParsePage 从查询参数解析分页, 参数无效时返回 CodeBadRequest
+func ParsePage(ctx *gin.Context, opts ...PageOption) (Pagination, error) {
+	cfg := &pageConfig{size: 20, maxSize: 100}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	p := Pagination{Page: 1, Size: cfg.size, Cursor: ctx.Query(QueryCursor)}
+	if s := ctx.Query(QuerySize); s != "" {
+		size, err := strconv.Atoi(s)
+		if err != nil || size < 1 {
+			return Pagination{}, CodeBadRequest.Errorf("%s 必须是正整数", QuerySize)
+		}
+		p.Size = size
+	}
+	if cfg.maxSize > 0 {
+		p.Size = min(p.Size, cfg.maxSize)
+	}
+
+	if p.Cursor != "" {
+		p.Page = 0
+		return p, nil
+	}
+	if s := ctx.Query(QueryPage); s != "" {
+		page, err := strconv.Atoi(s)
+		if err != nil || page < 1 {
+			return Pagination{}, CodeBadRequest.Errorf("%s 必须是正整数", QueryPage)
+		}
+		p.Page = page
+	}
+	return p, nil
+}
+
+// Page 分页结果, 游标分页时 Total 可以为 0
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	Size       int    `json:"size"`
+	HasNext    bool   `json:"has_next"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPage 页码分页结果
+func NewPage[T any](items []T, total int64, p Pagination) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return Page[T]{
+		Items:   items,
+		Total:   total,
+		Page:    p.Page,
+		Size:    p.Size,
+		HasNext: int64(p.Offset()+len(items)) < total,
+	}
+}
+
+// NewCursorPage 游标分页结果, next 为空表示没有下一页
+func NewCursorPage[T any](items []T, next string, p Pagination) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return Page[T]{Items: items, Size: p.Size, HasNext: next != "", NextCursor: next}
+}
+
+// Paged 返回分页数据, 并设置 RFC 5988 Link 和 X-Total-Count 响应头
+func Paged[T any](ctx *gin.Context, msg string, page Page[T]) {
+	if links := pageLinks(ctx.Request, page); len(links) > 0 {
+		ctx.Header("Link", strings.Join(links, ", "))
+	}
+	if page.Page > 0 {
+		ctx.Header("X-Total-Count", strconv.FormatInt(page.Total, 10))
+	}
+	Render(ctx, http.StatusOK, &JsonMsg{Code: http.StatusOK, Msg: translate(ctx, msg), Data: page})
+}
+
+// pageLinks 生成 first/prev/next/last 链接, 游标分页只有 next
+func pageLinks[T any](r *http.Request, page Page[T]) []string {
+	link := func(rel string, set map[string]string) string {
+		u := *r.URL
+		if u.Host == "" {
+			u.Host = r.Host
+		}
+		if u.Scheme == "" && u.Host != "" {
+			u.Scheme = "http"
+			if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+				u.Scheme = "https"
+			}
+		}
+		query := u.Query()
+		for k, v := range set {
+			query.Set(k, v)
+		}
+		query.Set(QuerySize, strconv.Itoa(page.Size))
+		u.RawQuery = query.Encode()
+		return fmt.Sprintf("<%s>; rel=%q", u.String(), rel)
+	}
+	pageQuery := func(n int) map[string]string {
+		return map[string]string{QueryPage: strconv.Itoa(n)}
+	}
+
+	var links []string
+	if page.Page == 0 {
+		if page.HasNext {
+			links = append(links, link("next", map[string]string{QueryCursor: page.NextCursor}))
+		}
+		return links
+	}
+
+	last := 1
+	if page.Size > 0 && page.Total > 0 {
+		last = int((page.Total + int64(page.Size) - 1) / int64(page.Size))
+	}
+	links = append(links, link("first", pageQuery(1)))
+	if page.Page > 1 {
+		links = append(links, link("prev", pageQuery(min(page.Page-1, last))))
+	}
+	if page.HasNext {
+		links = append(links, link("next", pageQuery(page.Page+1)))
+	}
+	links = append(links, link("last", pageQuery(last)))
+	return links
+}
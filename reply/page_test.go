@@ -0,0 +1,86 @@
+package reply
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testContext(target string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	ctx.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	return ctx, w
+}
+
+func TestParsePage(t *testing.T) {
+	cases := map[string]Pagination{
+		"/users":                   {Page: 1, Size: 20},
+		"/users?page=3&size=10":    {Page: 3, Size: 10},
+		"/users?size=500":          {Page: 1, Size: 100},
+		"/users?cursor=abc&page=2": {Size: 20, Cursor: "abc"},
+	}
+	for target, want := range cases {
+		ctx, _ := testContext(target)
+		if got, err := ParsePage(ctx); err != nil || got != want {
+			t.Errorf("%s: got %+v, %v", target, got, err)
+		}
+	}
+
+	for _, target := range []string{"/users?page=0", "/users?size=x", "/users?page=-1"} {
+		ctx, _ := testContext(target)
+		if _, err := ParsePage(ctx, WithPageSize(10, 50)); !errors.Is(err, CodeBadRequest) {
+			t.Errorf("%s: expected bad request, got %v", target, err)
+		}
+	}
+
+	ctx, _ := testContext("/users?page=2")
+	if p, _ := ParsePage(ctx, WithPageSize(10, 50)); p.Offset() != 10 || p.Size != 10 {
+		t.Errorf("unexpected pagination %+v", p)
+	}
+}
+
+func TestPaged(t *testing.T) {
+	ctx, w := testContext("/users?page=2&size=10&q=a")
+	p, _ := ParsePage(ctx)
+	Paged(ctx, "ok", NewPage([]string{"a", "b"}, 35, p))
+
+	var body struct {
+		Code int          `json:"code"`
+		Data Page[string] `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body.Code != 200 || body.Data.Total != 35 || !body.Data.HasNext || len(body.Data.Items) != 2 {
+		t.Errorf("unexpected body %s", w.Body)
+	}
+	if w.Header().Get("X-Total-Count") != "35" {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+	link := w.Header().Get("Link")
+	for _, want := range []string{
+		`<http://example.com/users?page=1&q=a&size=10>; rel="first"`,
+		`<http://example.com/users?page=1&q=a&size=10>; rel="prev"`,
+		`<http://example.com/users?page=3&q=a&size=10>; rel="next"`,
+		`<http://example.com/users?page=4&q=a&size=10>; rel="last"`,
+	} {
+		if !strings.Contains(link, want) {
+			t.Errorf("missing %s in %s", want, link)
+		}
+	}
+
+	ctx, w = testContext("/events?cursor=c1")
+	p, _ = ParsePage(ctx)
+	Paged(ctx, "ok", NewCursorPage[int](nil, "c2", p))
+	if link := w.Header().Get("Link"); link != `<http://example.com/events?cursor=c2&size=20>; rel="next"` {
+		t.Errorf("unexpected cursor link %q", link)
+	}
+	if !strings.Contains(w.Body.String(), `"items":[]`) || !strings.Contains(w.Body.String(), `"next_cursor":"c2"`) {
+		t.Errorf("unexpected body %s", w.Body)
+	}
+}
@@ -64,6 +64,7 @@ func Client(ctx *gin.Context, jm *JsonMsg, opts ...Option) {
 	for _, opt := range opts {
 		opt(jm)
 	}
+	jm.Msg = translate(ctx, jm.Msg)
 
 	// 设置默认的 HTTP 状态码
 	statusCode := http.StatusOK
@@ -71,15 +72,15 @@ func Client(ctx *gin.Context, jm *JsonMsg, opts ...Option) {
 		statusCode = jm.Code
 	}
 
-	// 返回 JSON 响应
-	ctx.JSON(statusCode, jm)
+	// 按 Accept 返回 JSON/XML/YAML 等格式
+	Render(ctx, statusCode, jm)
 }
 
 // Succeed 正确数据
 func Succeed(ctx *gin.Context, msg string, others ...gin.H) {
 	rt := gin.H{
 		"code": http.StatusOK,
-		"msg":  msg,
+		"msg":  translate(ctx, msg),
 	}
 
 	if len(others) != 0 {
@@ -90,14 +91,14 @@ func Succeed(ctx *gin.Context, msg string, others ...gin.H) {
 		}
 	}
 
-	ctx.JSON(http.StatusOK, rt)
+	Render(ctx, http.StatusOK, rt)
 }
 
 // Failed 错误数据
 func Failed(ctx *gin.Context, msg, err string, others ...gin.H) {
 	rt := gin.H{
 		"code": http.StatusBadRequest,
-		"msg":  msg,
+		"msg":  translate(ctx, msg),
 		"err":  err,
 	}
 
@@ -109,5 +110,5 @@ func Failed(ctx *gin.Context, msg, err string, others ...gin.H) {
 		}
 	}
 
-	ctx.JSON(rt["code"].(int), rt)
+	Render(ctx, rt["code"].(int), rt)
 }
@@ -0,0 +1,24 @@
+package reply
+
+import (
+	"net/http"
+
+	"github.com/Fromsko/gouitls/reply/api"
+	"github.com/gin-gonic/gin"
+)
+
+// OK 返回成功数据, 格式为 api.Response[T], msg 为空时使用 "success", msg 可以是消息 key.
+// 客户端可以用 api.Decode 或 knet/v2 的 DecodeReply 解析
+func OK[T any](ctx *gin.Context, data T, msg ...string) {
+	resp := api.Response[T]{Code: api.SuccessCode, Msg: "success", Data: data}
+	if len(msg) > 0 {
+		resp.Msg = msg[0]
+	}
+	resp.Msg = translate(ctx, resp.Msg)
+	Render(ctx, http.StatusOK, resp)
+}
+
+// Fail 返回错误, 与 OK 对应, 状态码和业务码的映射同 Error
+func Fail(ctx *gin.Context, err error) {
+	Error(ctx, err)
+}
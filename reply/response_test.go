@@ -0,0 +1,48 @@
+package reply
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Fromsko/gouitls/reply/api"
+)
+
+func TestOK(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+	ctx, w := testContext("/")
+	OK(ctx, user{Name: "alice"})
+
+	resp, err := api.Decode[user](w.Code, w.Body.Bytes())
+	if err != nil || resp.Code != api.SuccessCode || resp.Msg != "success" || resp.Data.Name != "alice" {
+		t.Errorf("unexpected response %+v, %v", resp, err)
+	}
+
+	// 与 JsonMsg 的结构一致
+	var jm JsonMsg
+	if err := json.Unmarshal(w.Body.Bytes(), &jm); err != nil || jm.Data.(map[string]any)["name"] != "alice" {
+		t.Errorf("unexpected JsonMsg %+v, %v", jm, err)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	ctx, w := testContext("/")
+	Fail(ctx, CodeNotFound.New("用户不存在"))
+
+	_, err := api.Decode[struct{}](w.Code, w.Body.Bytes())
+	var app *AppError
+	if !errors.Is(err, CodeNotFound) || !errors.As(err, &app) || app.Message() != "用户不存在" || app.Code.Status != http.StatusNotFound {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// Failed 和未注册的业务码
+	ctx, w = testContext("/")
+	Failed(ctx, "登录失败", "bad password")
+	_, err = api.Decode[any](w.Code, w.Body.Bytes())
+	if !errors.As(err, &app) || app.Code.Code != http.StatusBadRequest || app.Cause == nil || app.Cause.Error() != "bad password" {
+		t.Errorf("unexpected error %v", err)
+	}
+}